@@ -0,0 +1,98 @@
+// Command eva-dot draws a genome of a saved population as a Graphviz graph.
+//
+//	go run ./cmd/eva-dot -pop runs/exp1/generations/generation_99.json -out best.svg
+//	go run ./cmd/eva-dot -pop runs/exp1/generations/generation_99.json -species 3 -genom 0 -out g.dot
+//
+// .dot files are written directly, any other extension (.svg, .png, .pdf) is rendered
+// with the dot program, which has to be installed. Without -species the best genome is drawn.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"projectEVA/data"
+	"projectEVA/sim"
+	"strings"
+)
+
+func main() {
+	popFile := flag.String("pop", "", "saved population: generation_N.json, or a generation_N.txt dump")
+	speciesID := flag.Int("species", -1, "ID of the species to take the genome from (-1 - the best genome of the population)")
+	genomIndex := flag.Int("genom", 0, "index of the genome within the species")
+	out := flag.String("out", "genom.dot", "output file, .dot or any format dot can render (.svg, .png, ...)")
+	flag.Parse()
+
+	if *popFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	pop, err := loadPopulation(*popFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	genom, err := pickGenom(pop, *speciesID, *genomIndex)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	inputNames, outputNames := sim.InputNames, sim.OutputNames
+	if genom.NumInputs == data.CPPNInputs && genom.NumOutputs == data.CPPNOutputs {
+		// HyperNEAT run, the genome is a CPPN
+		inputNames, outputNames = data.CPPNInputNames, data.CPPNOutputNames
+	}
+	if err := write(*out, genom.ToDOT(inputNames, outputNames)); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("genom with fitness %.2f (%d nodes, %d connections) written to %s", genom.Fitness, len(genom.Nodes), len(genom.Connections), *out)
+}
+
+func loadPopulation(filename string) (*data.Population, error) {
+	if filepath.Ext(filename) == ".txt" {
+		return data.LoadPopulationFromFile(filename, &data.InnovationHistory{})
+	}
+	return data.LoadPopulationJSON(filename)
+}
+
+func pickGenom(pop *data.Population, speciesID, index int) (*data.Genom, error) {
+	if speciesID < 0 {
+		var best *data.Genom
+		for _, genom := range data.AllGenomesFromPopulation(pop) {
+			if best == nil || genom.Fitness > best.Fitness {
+				best = genom
+			}
+		}
+		if best == nil {
+			return nil, fmt.Errorf("the population has no genomes")
+		}
+		return best, nil
+	}
+	for _, species := range pop.AllSpecies {
+		if species.ID != speciesID {
+			continue
+		}
+		if index < 0 || index >= len(species.Genoms) {
+			return nil, fmt.Errorf("species %d has %d genomes, there is no genom %d", speciesID, len(species.Genoms), index)
+		}
+		return species.Genoms[index], nil
+	}
+	return nil, fmt.Errorf("there is no species %d", speciesID)
+}
+
+func write(filename, dot string) error {
+	// writes the graph, rendering it with dot unless a .dot file was asked for
+	format := strings.TrimPrefix(filepath.Ext(filename), ".")
+	if format == "dot" || format == "gv" {
+		return os.WriteFile(filename, []byte(dot), 0644)
+	}
+	if _, err := exec.LookPath("dot"); err != nil {
+		return fmt.Errorf("rendering %s needs Graphviz (the dot program), write a .dot file instead: %w", filename, err)
+	}
+	cmd := exec.Command("dot", "-T"+format, "-o", filename)
+	cmd.Stdin = strings.NewReader(dot)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,89 @@
+// Command eva-train evolves EVA agents without opening a window.
+//
+//	go run ./cmd/eva-train -pop 30 -generations 100 -seed 42 -out runs/exp1
+//
+// A stopped run is continued from its last checkpoint with
+//
+//	go run ./cmd/eva-train -resume runs/exp1/checkpoint.json -generations 150
+package main
+
+import (
+	"flag"
+	"log"
+	"projectEVA/data"
+	"projectEVA/trainer"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func main() {
+	cfg := trainer.Config{}
+	flag.IntVar(&cfg.PopSize, "pop", 30, "number of genomes in a generation")
+	flag.IntVar(&cfg.Generations, "generations", 50, "number of generations to evolve")
+	flag.IntVar(&cfg.LifetimeSeconds, "lifetime", 30, "lifetime of a single genom in seconds of game time")
+	flag.Uint64Var(&cfg.Seed, "seed", 0, "random seed (0 picks one from the clock)")
+	flag.StringVar(&cfg.OutDir, "out", "runs", "directory for saved generations and the fitness log")
+	flag.IntVar(&cfg.Diet, "diet", 2, "diet of the player (0 - carnivore, 1 - herbivore, 2 - omnivore)")
+	flag.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "number of genomes evaluated in parallel")
+	flag.IntVar(&cfg.CheckpointEvery, "checkpoint-every", 5, "write a checkpoint every N generations (0 - never)")
+	flag.BoolVar(&cfg.Recurrent, "recurrent", false, "evolve recurrent networks (cycles allowed, memory between frames)")
+	flag.IntVar(&cfg.HallOfFame, "hall-of-fame", trainer.DefaultHallOfFame, "number of all-time best genomes kept in <out>/champions.json")
+	flag.StringVar(&cfg.Fitness, "fitness", data.DefaultFitness, "fitness function ("+strings.Join(data.FitnessNames(), ", ")+")")
+	flag.BoolVar(&cfg.HyperNEAT, "hyperneat", false, "evolve CPPNs painting the weights of a retina substrate (a grid of food, enemies and vitamins around the player)")
+	neatFile := flag.String("config", "", "JSON file with NEAT hyperparameters (missing values keep their defaults)")
+	writeConfig := flag.String("write-config", "", "write the default NEAT hyperparameters to this file and exit")
+	resume := flag.String("resume", "", "continue the run saved in this checkpoint")
+	flag.Parse()
+
+	if *writeConfig != "" {
+		if err := data.SaveNEATConfig(*writeConfig, data.DefaultNEATConfig()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var t *trainer.Trainer
+	if *resume != "" {
+		var err error
+		t, err = trainer.Resume(*resume)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// workers depend on the machine, not on the run - they don't change the results
+		t.Config.Workers = cfg.Workers
+		// the rest of the config comes from the checkpoint, so the run stays the same
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "generations":
+				t.Config.Generations = cfg.Generations
+			case "checkpoint-every":
+				t.Config.CheckpointEvery = cfg.CheckpointEvery
+			case "hall-of-fame":
+				t.Config.HallOfFame = cfg.HallOfFame
+				t.Archive.Size = cfg.HallOfFame
+			}
+		})
+		log.Printf("resuming %s at generation %d of %d on %d workers", *resume, t.Population.CurrentGeneration, t.Config.Generations, t.Config.Workers)
+		if *neatFile != "" {
+			log.Printf("-config is ignored when resuming, the run keeps the hyperparameters it was started with")
+		}
+	} else {
+		if *neatFile != "" {
+			neat, err := data.LoadNEATConfig(*neatFile)
+			if err != nil {
+				log.Fatal(err)
+			}
+			cfg.NEAT = &neat
+		}
+		if cfg.Seed == 0 {
+			cfg.Seed = uint64(time.Now().UnixNano())
+		}
+		log.Printf("training %d genomes for %d generations on %d workers, seed %d", cfg.PopSize, cfg.Generations, cfg.Workers, cfg.Seed)
+		t = trainer.New(cfg)
+	}
+
+	if err := t.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
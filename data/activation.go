@@ -0,0 +1,127 @@
+package data
+
+import (
+	"fmt"
+	"math"
+)
+
+// – – – – – – – – – – – – – – – – ACTIVATION FUNCTIONS – – – – – – – – – – – – – – – – – – –
+// every hidden and output node carries its activation function as a gene,
+// so evolution can pick it (and the networks can be used as CPPNs)
+
+type Activation int
+
+const (
+	DefaultActivation Activation = iota // relu for hidden nodes, sigmoid for outputs (nodes saved before activations were genes)
+	Tanh
+	Sigmoid // scaled sigmoid, range [-1,1]
+	ReLU
+	Gaussian
+	Sin
+	Abs
+	Step
+	Identity
+)
+
+// Activations lists every activation function a mutation can pick
+var Activations = []Activation{Tanh, Sigmoid, ReLU, Gaussian, Sin, Abs, Step, Identity}
+
+// OutputActivations lists activation functions an output node may get,
+// outputs steer the player, so they have to stay in [-1,1]
+var OutputActivations = []Activation{Tanh, Sigmoid}
+
+func (node *Node) activation() Activation {
+	// helper function
+	// resolves DefaultActivation to the function the node type always used
+	if node.Activation != DefaultActivation {
+		return node.Activation
+	}
+	if node.Type == Output {
+		return Sigmoid
+	}
+	return ReLU
+}
+
+func (a Activation) Apply(x float64) float64 {
+	switch a {
+	case Tanh:
+		return math.Tanh(x)
+	case Sigmoid:
+		return sigmoid(x)
+	case ReLU:
+		return relu(x)
+	case Gaussian:
+		return math.Exp(-x * x)
+	case Sin:
+		return math.Sin(x)
+	case Abs:
+		return math.Abs(x)
+	case Step:
+		if x > 0 {
+			return 1
+		}
+		return 0
+	case Identity:
+		return x
+	}
+	// DefaultActivation needs a node type, see Node.activation
+	return relu(x)
+}
+
+func (a Activation) String() string {
+	switch a {
+	case DefaultActivation:
+		return "default"
+	case Tanh:
+		return "tanh"
+	case Sigmoid:
+		return "sigmoid"
+	case ReLU:
+		return "relu"
+	case Gaussian:
+		return "gaussian"
+	case Sin:
+		return "sin"
+	case Abs:
+		return "abs"
+	case Step:
+		return "step"
+	case Identity:
+		return "identity"
+	}
+	return fmt.Sprintf("Activation(%d)", int(a))
+}
+
+func (a Activation) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Activation) UnmarshalText(text []byte) error {
+	for _, known := range append([]Activation{DefaultActivation}, Activations...) {
+		if known.String() == string(text) {
+			*a = known
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown activation %q", text)
+}
+
+func (genom *Genom) mutateActivation() {
+	// mutates genome by giving a random hidden or output node a new activation function
+	candidates := []*Node{}
+	for _, node := range genom.Nodes {
+		if node.Type != Input && node.Type != Bias {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	node := candidates[genom.rng().IntN(len(candidates))]
+	choices := Activations
+	if node.Type == Output {
+		choices = OutputActivations
+	}
+	node.Activation = choices[genom.rng().IntN(len(choices))]
+	genom.net = nil
+}
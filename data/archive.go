@@ -0,0 +1,176 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// – – – – – – – – – – – – – – – – CHAMPION ARCHIVE – – – – – – – – – – – – – – – – – – – – –
+// elites survive only as long as nothing beats them, the archive keeps the best genomes
+// of the whole run (with what they did to earn their fitness), so they can be watched later
+
+type Champion struct {
+	Generation int          `json:"generation"`
+	SpeciesID  int          `json:"species_id"`
+	Fitness    float64      `json:"fitness"`
+	Stats      EpisodeStats `json:"stats"`
+	Genom      *Genom       `json:"genom"`
+}
+
+type ChampionArchive struct {
+	Size          int        `json:"size"`           // number of all-time champions kept
+	PerGeneration []Champion `json:"per_generation"` // best genom of every generation, in generation order
+	PerSpecies    []Champion `json:"per_species"`    // best genom every species ever had, in species ID order
+	AllTime       []Champion `json:"all_time"`       // Size best genomes of the run, best first
+	HyperNEAT     bool       `json:"hyperneat"`      // genomes are CPPNs painting the retina substrate, not the agent's brain
+}
+
+// ChampionArchiveFormatVersion is written to every saved archive
+const ChampionArchiveFormatVersion = 1
+
+func NewChampionArchive(size int) *ChampionArchive {
+	return &ChampionArchive{
+		Size:          size,
+		PerGeneration: []Champion{},
+		PerSpecies:    []Champion{},
+		AllTime:       []Champion{},
+	}
+}
+
+func (a *ChampionArchive) Record(pop *Population) {
+	// adds champions of the evaluated, speciated generation pop.CurrentGeneration
+	// genomes are cloned, so breeding doesn't change archived ones
+	var best *Champion
+	for _, species := range pop.AllSpecies {
+		var speciesBest *Genom
+		for _, genom := range species.Genoms {
+			if speciesBest == nil || genom.Fitness > speciesBest.Fitness {
+				speciesBest = genom
+			}
+			a.recordAllTime(genom, pop.CurrentGeneration, species.ID)
+		}
+		if speciesBest == nil {
+			continue
+		}
+		champion := newChampion(speciesBest, pop.CurrentGeneration, species.ID)
+		a.recordSpecies(champion)
+		if best == nil || champion.Fitness > best.Fitness {
+			best = &champion
+		}
+	}
+	if best == nil {
+		return
+	}
+	// a generation evaluated again (e.g. after loading a saved population) replaces the old entry
+	for i := range a.PerGeneration {
+		if a.PerGeneration[i].Generation == best.Generation {
+			a.PerGeneration[i] = *best
+			return
+		}
+	}
+	a.PerGeneration = append(a.PerGeneration, *best)
+}
+
+func newChampion(genom *Genom, generation, speciesID int) Champion {
+	// helper function
+	return Champion{
+		Generation: generation,
+		SpeciesID:  speciesID,
+		Fitness:    genom.Fitness,
+		Stats:      genom.Stats,
+		Genom:      CloneGenom(genom),
+	}
+}
+
+func (a *ChampionArchive) recordSpecies(champion Champion) {
+	// helper function
+	i := sort.Search(len(a.PerSpecies), func(i int) bool {
+		return a.PerSpecies[i].SpeciesID >= champion.SpeciesID
+	})
+	if i < len(a.PerSpecies) && a.PerSpecies[i].SpeciesID == champion.SpeciesID {
+		if champion.Fitness > a.PerSpecies[i].Fitness {
+			a.PerSpecies[i] = champion
+		}
+		return
+	}
+	a.PerSpecies = append(a.PerSpecies, Champion{})
+	copy(a.PerSpecies[i+1:], a.PerSpecies[i:])
+	a.PerSpecies[i] = champion
+}
+
+func (a *ChampionArchive) recordAllTime(genom *Genom, generation, speciesID int) {
+	// helper function
+	// elites are copied unchanged into the next generations,
+	// so the same genes are kept only once, with their best episode
+	if len(a.AllTime) >= a.Size && (a.Size <= 0 || genom.Fitness <= a.AllTime[len(a.AllTime)-1].Fitness) {
+		return
+	}
+	for i, entry := range a.AllTime {
+		if sameGenes(entry.Genom, genom) {
+			if genom.Fitness <= entry.Fitness {
+				return
+			}
+			a.AllTime = append(a.AllTime[:i], a.AllTime[i+1:]...)
+			break
+		}
+	}
+	a.AllTime = append(a.AllTime, newChampion(genom, generation, speciesID))
+	sort.SliceStable(a.AllTime, func(i, j int) bool {
+		return a.AllTime[i].Fitness > a.AllTime[j].Fitness
+	})
+	if len(a.AllTime) > a.Size {
+		a.AllTime = a.AllTime[:a.Size]
+	}
+}
+
+func sameGenes(genom1, genom2 *Genom) bool {
+	// helper function
+	// checks, if two genomes are copies of each other
+	if len(genom1.Nodes) != len(genom2.Nodes) || len(genom1.Connections) != len(genom2.Connections) {
+		return false
+	}
+	for i, node := range genom1.Nodes {
+		other := genom2.Nodes[i]
+		if node.ID != other.ID || node.Type != other.Type || node.Activation != other.Activation {
+			return false
+		}
+	}
+	for i, conn := range genom1.Connections {
+		other := genom2.Connections[i]
+		if conn.Innovation != other.Innovation || conn.Weight != other.Weight || conn.Enabled != other.Enabled {
+			return false
+		}
+	}
+	return true
+}
+
+type championArchiveFile struct {
+	Version int `json:"version"`
+	*ChampionArchive
+}
+
+func SaveChampionArchive(filename string, a *ChampionArchive) error {
+	b, err := json.MarshalIndent(championArchiveFile{Version: ChampionArchiveFormatVersion, ChampionArchive: a}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+func LoadChampionArchive(filename string) (*ChampionArchive, error) {
+	// loaded genomes have no innovation history, they are meant to be watched, not bred
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	af := championArchiveFile{ChampionArchive: NewChampionArchive(0)}
+	if err := json.Unmarshal(b, &af); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if af.Version != ChampionArchiveFormatVersion {
+		return nil, fmt.Errorf("%s: unsupported champion archive format version %d (supported: %d)", filename, af.Version, ChampionArchiveFormatVersion)
+	}
+	return af.ChampionArchive, nil
+}
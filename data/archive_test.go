@@ -0,0 +1,79 @@
+package data
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// archivePop builds a speciated population with the given fitness of every genom, by species ID
+func archivePop(generation int, fitness map[int][]float64) *Population {
+	pop := &Population{CurrentGeneration: generation}
+	for id := 0; id < len(fitness); id++ {
+		species := &Species{ID: id}
+		for _, f := range fitness[id] {
+			genom := validGenom()
+			genom.Fitness = f
+			genom.Connections[0].Weight = f // validGenom always builds the same genes
+			genom.Stats = EpisodeStats{FoodEaten: int(f)}
+			species.Genoms = append(species.Genoms, genom)
+		}
+		pop.AllSpecies = append(pop.AllSpecies, species)
+	}
+	return pop
+}
+
+func TestChampionArchive(t *testing.T) {
+	a := NewChampionArchive(3)
+	a.Record(archivePop(0, map[int][]float64{0: {5, 1}, 1: {3}}))
+	pop := archivePop(1, map[int][]float64{0: {2}, 1: {4, 9}})
+	a.Record(pop)
+	// an elite copied unchanged into the next generation is archived only once
+	elite := CloneGenom(pop.AllSpecies[1].Genoms[1])
+	elite.Fitness = 10
+	a.Record(&Population{CurrentGeneration: 2, AllSpecies: []*Species{{ID: 1, Genoms: []*Genom{elite}}}})
+
+	fitness := func(champions []Champion) []float64 {
+		f := []float64{}
+		for _, c := range champions {
+			f = append(f, c.Fitness)
+		}
+		return f
+	}
+	check := func(name string, got, want []float64) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", name, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %v, want %v", name, got, want)
+			}
+		}
+	}
+	check("per generation", fitness(a.PerGeneration), []float64{5, 9, 10})
+	check("per species", fitness(a.PerSpecies), []float64{5, 10})
+	check("all time", fitness(a.AllTime), []float64{10, 5, 4})
+	if a.AllTime[0].Generation != 2 || a.AllTime[0].SpeciesID != 1 || a.AllTime[0].Stats.FoodEaten != 9 {
+		t.Fatalf("best champion is %+v", a.AllTime[0])
+	}
+
+	// archived genomes don't change with the population
+	pop.AllSpecies[1].Genoms[1].Connections[0].Weight += 1
+	if a.PerGeneration[1].Genom.Connections[0].Weight == pop.AllSpecies[1].Genoms[1].Connections[0].Weight {
+		t.Fatal("archived genom shares connections with the population")
+	}
+
+	a.HyperNEAT = true
+	filename := filepath.Join(t.TempDir(), "champions.json")
+	if err := SaveChampionArchive(filename, a); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadChampionArchive(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("loaded all time", fitness(loaded.AllTime), []float64{10, 5, 4})
+	if loaded.Size != 3 || !loaded.HyperNEAT || !sameGenes(loaded.AllTime[0].Genom, a.AllTime[0].Genom) {
+		t.Fatal("loaded archive differs from the saved one")
+	}
+}
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+// genomWith builds a genom which only has the connections (innovation -> weight)
+// the compatibility distance looks at
+func genomWith(genes map[int]float64) *Genom {
+	genom := &Genom{}
+	in, out := &Node{ID: 0, Type: Input}, &Node{ID: 1, Type: Output}
+	genom.Nodes = []*Node{in, out}
+	for innovation, weight := range genes {
+		genom.Connections = append(genom.Connections, Connection{
+			InNode:     in,
+			OutNode:    out,
+			Weight:     weight,
+			Innovation: innovation,
+			Enabled:    true,
+		})
+	}
+	return genom
+}
+
+func TestGeneDifferences(t *testing.T) {
+	// innovations:  1     2     3    4     5    6    7    8
+	// genom a:      0.5  -0.5   1.0  0.0   0.2
+	// genom b:      0.0   0.5        -1.0       0.3  0.1  0.9
+	// matching 1, 2, 4 -> |0.5| + |-1.0| + |1.0| = 2.5, mean 2.5/3
+	// 3 and 5 are disjoint (below b's highest innovation 8)
+	// 6, 7 and 8 are excess (above a's highest innovation 5)
+	a := genomWith(map[int]float64{1: 0.5, 2: -0.5, 3: 1.0, 4: 0.0, 5: 0.2})
+	b := genomWith(map[int]float64{1: 0.0, 2: 0.5, 4: -1.0, 6: 0.3, 7: 0.1, 8: 0.9})
+
+	excess, disjoint, weightDiff := geneDifferences(a, b)
+	if excess != 3 || disjoint != 2 {
+		t.Errorf("excess, disjoint = %d, %d, want 3, 2", excess, disjoint)
+	}
+	if math.Abs(weightDiff-2.5/3) > 1e-12 {
+		t.Errorf("weightDiff = %v, want %v", weightDiff, 2.5/3)
+	}
+
+	// the distance is symmetric
+	excess, disjoint, weightDiff = geneDifferences(b, a)
+	if excess != 3 || disjoint != 2 || math.Abs(weightDiff-2.5/3) > 1e-12 {
+		t.Errorf("reversed: %d, %d, %v, want 3, 2, %v", excess, disjoint, weightDiff, 2.5/3)
+	}
+}
+
+func TestCompatibility(t *testing.T) {
+	a := genomWith(map[int]float64{1: 0.5, 2: -0.5, 3: 1.0, 4: 0.0, 5: 0.2})
+	b := genomWith(map[int]float64{1: 0.0, 2: 0.5, 4: -1.0, 6: 0.3, 7: 0.1, 8: 0.9})
+
+	tests := []struct {
+		name       string
+		c1, c2, c3 float64
+		small      int
+		want       float64
+	}{
+		// N = 6 (b has 6 genes): 1·3/6 + 1·2/6 + 0.4·2.5/3
+		{"normalized", 1, 1, 0.4, 0, 3.0/6 + 2.0/6 + 0.4*2.5/3},
+		// both genomes are smaller than 20 genes, so N = 1: 1·3 + 1·2 + 0.4·2.5/3
+		{"small genomes", 1, 1, 0.4, 20, 3 + 2 + 0.4*2.5/3},
+		// coefficients are applied separately: 2·3/6 + 0.5·2/6 + 3·2.5/3
+		{"separate coefficients", 2, 0.5, 3, 6, 2*3.0/6 + 0.5*2.0/6 + 3*2.5/3},
+	}
+	for _, tt := range tests {
+		pop := &Population{Config: NEATConfig{C1: tt.c1, C2: tt.c2, C3: tt.c3, SmallGenomeGenes: tt.small}}
+		if got := pop.Compatibility(a, b); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: Compatibility = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompatibilityOppositeWeightChanges(t *testing.T) {
+	// weight differences of +2 and -2 must not cancel out: W̄ = (2 + 2) / 2
+	a := genomWith(map[int]float64{1: 1, 2: -1})
+	b := genomWith(map[int]float64{1: -1, 2: 1})
+	pop := &Population{Config: NEATConfig{C1: 1, C2: 1, C3: 1}}
+	if got := pop.Compatibility(a, b); got != 2 {
+		t.Errorf("Compatibility = %v, want 2", got)
+	}
+}
+
+func TestCompatibilityNoMatchingGenes(t *testing.T) {
+	// nothing to average the weights over - W̄ is 0, not NaN
+	// 1 is disjoint (below b's highest innovation), 2 is excess
+	a := genomWith(map[int]float64{1: 0.7})
+	b := genomWith(map[int]float64{2: -0.3})
+	pop := &Population{Config: NEATConfig{C1: 1, C2: 1, C3: 1, SmallGenomeGenes: 20}}
+	if got := pop.Compatibility(a, b); got != 2 {
+		t.Errorf("Compatibility = %v, want 2", got)
+	}
+
+	empty := genomWith(nil)
+	if got := pop.Compatibility(empty, empty); got != 0 {
+		t.Errorf("Compatibility of empty genomes = %v, want 0", got)
+	}
+}
+
+func TestCompatibilityIdentical(t *testing.T) {
+	a := genomWith(map[int]float64{1: 0.5, 2: -0.5, 3: 1.0})
+	pop := &Population{Config: NEATConfig{C1: 1, C2: 1, C3: 0.4, SmallGenomeGenes: 20}}
+	if got := pop.Compatibility(a, a); got != 0 {
+		t.Errorf("Compatibility of a genom with itself = %v, want 0", got)
+	}
+}
@@ -0,0 +1,199 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// – – – – – – – – – – – – – – – – HYPERPARAMETERS – – – – – – – – – – – – – – – – – – – – – –
+// every knob of the algorithm in one place, so sweeps don't need code changes
+// a config is saved with every population, so a run always knows what it was evolved with
+
+type NEATConfig struct {
+	// new genomes
+	ConnCreationRate float64 `json:"conn_creation_rate"` // chance of every input x output connection in a new network
+
+	// mutations - chances per offspring
+	AddConnectionRate    float64 `json:"add_connection_rate"`
+	AddNodeRate          float64 `json:"add_node_rate"`
+	ToggleConnectionRate float64 `json:"toggle_connection_rate"`
+	ActivationRate       float64 `json:"activation_rate"` // chance of changing an activation function
+	DeleteConnectionRate float64 `json:"delete_connection_rate"`
+	DeleteNodeRate       float64 `json:"delete_node_rate"` // removes a hidden node with all its connections
+
+	// weight mutations
+	WeightMutationRate float64 `json:"weight_mutation_rate"` // chance every connection's weight is mutated
+	WeightPerturbRate  float64 `json:"weight_perturb_rate"`  // chance a mutated weight is nudged, otherwise it is replaced
+	WeightPerturbation string  `json:"weight_perturbation"`  // UniformPerturbation or GaussianPerturbation
+	WeightPerturbPower float64 `json:"weight_perturb_power"` // half width of the uniform nudge, sigma of the gaussian one
+	WeightSigmaDecay   float64 `json:"weight_sigma_decay"`   // WeightPerturbPower is multiplied by it every generation
+	SelfAdaptiveSigma  bool    `json:"self_adaptive_sigma"`  // every genome evolves its own perturbation power
+	MinWeight          float64 `json:"min_weight"`           // weights are kept within [MinWeight, MaxWeight],
+	MaxWeight          float64 `json:"max_weight"`           // both 0 - unbounded
+
+	// selection
+	TournamentSize    int     `json:"tournament_size"`
+	Elites            int     `json:"elites"`             // best genomes of the whole population copied unchanged
+	SpeciesElites     int     `json:"species_elites"`     // best genomes of every species copied unchanged
+	SurvivalThreshold float64 `json:"survival_threshold"` // only this top part of every species breeds
+	MultiObjective    bool    `json:"multi_objective"`    // rank by Pareto fronts of EpisodeStats.Objectives instead of fitness
+
+	// crossover
+	DisabledGeneRate       float64 `json:"disabled_gene_rate"`       // chance a gene disabled in either parent stays disabled
+	InterspeciesMatingRate float64 `json:"interspecies_mating_rate"` // chance the second parent comes from another species
+
+	// novelty search
+	NoveltyWeight      float64 `json:"novelty_weight"`       // 0 - selection by fitness only, 1 - by novelty only
+	NoveltyK           int     `json:"novelty_k"`            // number of nearest behaviours novelty is averaged over
+	NoveltyArchiveAdd  int     `json:"novelty_archive_add"`  // most novel behaviours added to the archive every generation
+	NoveltyArchiveSize int     `json:"novelty_archive_size"` // oldest behaviours are dropped above it
+
+	// speciation
+	C1               float64 `json:"c1"` // excess genes
+	C2               float64 `json:"c2"` // disjoint genes
+	C3               float64 `json:"c3"` // average weight difference
+	SmallGenomeGenes int     `json:"small_genome_genes"`
+	StagnationLimit  int     `json:"stagnation_limit"`
+	InitialThreshold float64 `json:"initial_threshold"`
+	TargetSpecies    int     `json:"target_species"` // Threshold is adjusted to get about this many species
+	ThresholdStep    float64 `json:"threshold_step"`
+	MinThreshold     float64 `json:"min_threshold"`
+	MaxThreshold     float64 `json:"max_threshold"`
+}
+
+// weight perturbation strategies
+const (
+	UniformPerturbation  = "uniform"  // nudge drawn uniformly from [-power, power)
+	GaussianPerturbation = "gaussian" // nudge drawn from a normal distribution with sigma = power
+)
+
+func DefaultNEATConfig() NEATConfig {
+	// values EVA was tuned with, operators added later are off
+	return NEATConfig{
+		ConnCreationRate: 1.0,
+
+		AddConnectionRate:    0.8,
+		AddNodeRate:          0.35,
+		ToggleConnectionRate: 0.1,
+		ActivationRate:       0,
+		DeleteConnectionRate: 0,
+		DeleteNodeRate:       0,
+
+		WeightMutationRate: 1.0,
+		WeightPerturbRate:  0.8,
+		WeightPerturbation: UniformPerturbation,
+		WeightPerturbPower: 0.2,
+		WeightSigmaDecay:   1.0,
+
+		TournamentSize:    3,
+		Elites:            2,
+		SpeciesElites:     0,
+		SurvivalThreshold: 1.0,
+
+		DisabledGeneRate:       0.75,
+		InterspeciesMatingRate: 0,
+
+		NoveltyK:           15,
+		NoveltyArchiveAdd:  2,
+		NoveltyArchiveSize: 500,
+
+		C1:               1.0,
+		C2:               1.0,
+		C3:               0.5,
+		SmallGenomeGenes: 20,
+		StagnationLimit:  15,
+		InitialThreshold: 3.0,
+		TargetSpecies:    8,
+		ThresholdStep:    0.1,
+		MinThreshold:     0.5,
+		MaxThreshold:     10.0,
+	}
+}
+
+func (cfg *NEATConfig) UnmarshalJSON(b []byte) error {
+	// fields missing in the JSON keep their defaults,
+	// so configs saved before a field existed still load with sensible values
+	type plain NEATConfig
+	decoded := plain(DefaultNEATConfig())
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	*cfg = NEATConfig(decoded)
+	return nil
+}
+
+func LoadNEATConfig(filename string) (NEATConfig, error) {
+	// reads a config file, values missing in the file keep their defaults
+	cfg := DefaultNEATConfig()
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", filename, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+func SaveNEATConfig(filename string, cfg NEATConfig) error {
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(b, '\n'), 0644)
+}
+
+func (cfg NEATConfig) Validate() error {
+	// catches values which would break evolution instead of just making it worse
+	switch {
+	case cfg.TournamentSize < 1:
+		return fmt.Errorf("tournament_size must be at least 1, got %d", cfg.TournamentSize)
+	case cfg.Elites < 0:
+		return fmt.Errorf("elites can't be negative, got %d", cfg.Elites)
+	case cfg.SpeciesElites < 0:
+		return fmt.Errorf("species_elites can't be negative, got %d", cfg.SpeciesElites)
+	case cfg.SurvivalThreshold <= 0 || cfg.SurvivalThreshold > 1:
+		return fmt.Errorf("survival_threshold must be in (0, 1], got %v", cfg.SurvivalThreshold)
+	case cfg.WeightPerturbation != UniformPerturbation && cfg.WeightPerturbation != GaussianPerturbation:
+		return fmt.Errorf("weight_perturbation must be %q or %q, got %q", UniformPerturbation, GaussianPerturbation, cfg.WeightPerturbation)
+	case cfg.WeightSigmaDecay <= 0:
+		return fmt.Errorf("weight_sigma_decay must be positive, got %v", cfg.WeightSigmaDecay)
+	case cfg.weightBounded() && (cfg.MinWeight > -1 || cfg.MaxWeight < 1):
+		// new weights are drawn from [-1, 1), see Genom.randomWeight
+		return fmt.Errorf("weights must be allowed at least in [-1, 1], got [%v, %v]", cfg.MinWeight, cfg.MaxWeight)
+	case cfg.DisabledGeneRate < 0 || cfg.DisabledGeneRate > 1:
+		return fmt.Errorf("disabled_gene_rate must be in [0, 1], got %v", cfg.DisabledGeneRate)
+	case cfg.NoveltyWeight < 0 || cfg.NoveltyWeight > 1:
+		return fmt.Errorf("novelty_weight must be in [0, 1], got %v", cfg.NoveltyWeight)
+	case cfg.NoveltyK < 1:
+		return fmt.Errorf("novelty_k must be at least 1, got %d", cfg.NoveltyK)
+	case cfg.MinThreshold > cfg.MaxThreshold:
+		return fmt.Errorf("min_threshold %v is above max_threshold %v", cfg.MinThreshold, cfg.MaxThreshold)
+	}
+	return nil
+}
+
+func (cfg *NEATConfig) weightSigma(generation int) float64 {
+	// helper function
+	// perturbation power of the given generation, after WeightSigmaDecay
+	return cfg.WeightPerturbPower * math.Pow(cfg.WeightSigmaDecay, float64(generation))
+}
+
+func (cfg *NEATConfig) weightBounded() bool {
+	// helper function
+	return cfg.MinWeight != 0 || cfg.MaxWeight != 0
+}
+
+func (cfg *NEATConfig) clampWeight(weight float64) float64 {
+	// helper function
+	// keeps the weight within [MinWeight, MaxWeight], if the bounds are set
+	if !cfg.weightBounded() {
+		return weight
+	}
+	return math.Max(cfg.MinWeight, math.Min(cfg.MaxWeight, weight))
+}
@@ -0,0 +1,142 @@
+package data
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// evolvedPair builds two genomes sharing an innovation history and
+// a common ancestor, but with different structural mutations on top
+func evolvedPair(rng *rand.Rand) (*Genom, *Genom) {
+	ih := &InnovationHistory{History: make(map[InnovationKey]int)}
+	ancestor := &Genom{NumInputs: 4, NumOutputs: 2, ConnCreationRate: 1.0, IH: ih, Rand: rng}
+	ancestor.CreateNetwork()
+
+	parents := [2]*Genom{CloneGenom(ancestor), CloneGenom(ancestor)}
+	for _, parent := range parents {
+		parent.Rand = rng
+		for i := 0; i < 4; i++ {
+			parent.mutateAddNode()
+			parent.mutateAddConnection()
+			parent.mutateToggleConnection()
+		}
+	}
+	return parents[0], parents[1]
+}
+
+// checkStructure fails the test if the genom isn't structurally valid
+func checkStructure(t *testing.T, genom *Genom) {
+	t.Helper()
+	if err := genom.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func innovationSet(genom *Genom) map[int]bool {
+	set := make(map[int]bool)
+	for _, conn := range genom.Connections {
+		set[conn.Innovation] = true
+	}
+	return set
+}
+
+func TestCrossoverStructure(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	cfg := DefaultNEATConfig()
+	for i := 0; i < 200; i++ {
+		parent1, parent2 := evolvedPair(rng)
+		parent1.Fitness = rng.Float64()
+		parent2.Fitness = rng.Float64()
+		if i%2 == 0 {
+			parent2.Fitness = parent1.Fitness
+		}
+		child := crossover(parent1, parent2, rng, &cfg)
+		checkStructure(t, child)
+
+		// the child must still be a valid genom after mutating
+		child.mutateAddNode()
+		child.mutateAddConnection()
+		checkStructure(t, child)
+		child.Forward(make([]float64, child.NumInputs))
+	}
+}
+
+func TestCrossoverUnequalFitness(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	cfg := DefaultNEATConfig()
+	for i := 0; i < 50; i++ {
+		parent1, parent2 := evolvedPair(rng)
+		parent1.Fitness, parent2.Fitness = 1, 2
+
+		child := crossover(parent1, parent2, rng, &cfg)
+		got, want := innovationSet(child), innovationSet(parent2)
+		if len(got) != len(want) {
+			t.Fatalf("child has %d genes, fitter parent has %d", len(got), len(want))
+		}
+		for innovation := range want {
+			if !got[innovation] {
+				t.Fatalf("gene %d of the fitter parent is missing", innovation)
+			}
+		}
+	}
+}
+
+func TestCrossoverEqualFitness(t *testing.T) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	cfg := DefaultNEATConfig()
+	for i := 0; i < 50; i++ {
+		parent1, parent2 := evolvedPair(rng)
+		parent1.Fitness, parent2.Fitness = 1, 1
+
+		child := crossover(parent1, parent2, rng, &cfg)
+		got := innovationSet(child)
+		for _, parent := range []*Genom{parent1, parent2} {
+			for innovation := range innovationSet(parent) {
+				if !got[innovation] {
+					t.Fatalf("gene %d of an equally fit parent is missing", innovation)
+				}
+			}
+		}
+	}
+}
+
+func TestCrossoverDisabledGenes(t *testing.T) {
+	rng := rand.New(rand.NewPCG(7, 8))
+	ih := &InnovationHistory{History: make(map[InnovationKey]int)}
+	parent1 := &Genom{NumInputs: 3, NumOutputs: 2, ConnCreationRate: 1.0, IH: ih, Rand: rng}
+	parent1.CreateNetwork()
+	parent2 := CloneGenom(parent1)
+	parent1.Fitness, parent2.Fitness = 1, 2
+	// every gene disabled in one of the parents only
+	for i := range parent1.Connections {
+		if i%2 == 0 {
+			parent1.Connections[i].Enabled = false
+		} else {
+			parent2.Connections[i].Enabled = false
+		}
+	}
+
+	cfg := DefaultNEATConfig()
+	disabled, total := 0, 0
+	for i := 0; i < 2000; i++ {
+		child := crossover(parent1, parent2, rng, &cfg)
+		for _, conn := range child.Connections {
+			total++
+			if !conn.Enabled {
+				disabled++
+			}
+		}
+	}
+	rate := float64(disabled) / float64(total)
+	if rate < cfg.DisabledGeneRate-0.02 || rate > cfg.DisabledGeneRate+0.02 {
+		t.Fatalf("%.3f of the genes stayed disabled, want about %.2f", rate, cfg.DisabledGeneRate)
+	}
+
+	// genes enabled in both parents stay enabled
+	child := crossover(parent1, CloneGenom(parent1), rng, &cfg)
+	for i, conn := range child.Connections {
+		if i%2 == 1 && !conn.Enabled {
+			t.Fatalf("gene %d enabled in both parents got disabled", conn.Innovation)
+		}
+	}
+}
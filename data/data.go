@@ -3,14 +3,15 @@ package data
 import (
 	"bufio"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"math"
-	"math/rand"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // – – – – – – – – – – – – – – DATA TYPES AND STRUCTURES – – – – – – – – – – – – – – – – –
@@ -21,11 +22,13 @@ const (
 	Input  NodeType = iota // Input = 0
 	Hidden                 // Hidden = 1
 	Output                 // Output = 2
+	Bias                   // Bias = 3, its value is always 1.0
 )
 
 type Node struct {
 	ID            int // unique node ID
 	Type          NodeType
+	Activation    Activation   // activation function gene (DefaultActivation - by node type)
 	IncomingConns []Connection // list of connections entering the node
 }
 
@@ -37,6 +40,14 @@ type Connection struct {
 	Enabled    bool
 }
 
+type SplitKey struct {
+	// key for the Splits map
+	// recognizes a split by the innovation number of the split connection
+	// and by how many times the genome had split it before (0 - the first split)
+	connection int
+	nth        int
+}
+
 type InnovationKey struct {
 	// key for the InnovationHistory map
 	// recognizes connection by IDs of nodes that make it up
@@ -49,6 +60,11 @@ type InnovationHistory struct {
 	// tracks innovation globally
 	History map[InnovationKey]int
 	Counter int
+	// map, that gives the hidden node created by splitting a connection (by its innovation number
+	// and how many times the genome split it before) the same ID in every genome, so the same
+	// structural mutation always gets the same node ID and, through History, the same innovation numbers
+	Splits      map[SplitKey]int
+	NodeCounter int // next free node ID
 }
 
 type Genom struct {
@@ -60,27 +76,44 @@ type Genom struct {
 	ConnCreationRate float64            // chance of adding connection while creating new network
 	IH               *InnovationHistory // global innovation history
 	Fitness          float64            // fitness score
+	Stats            EpisodeStats       // what the genome did in the episode Fitness comes from
+	Rand             *rand.Rand         // random number generator, shared like IH (must be set before mutating)
+	Recurrent        bool               // allows cycles and keeps node activations between Forward calls
+	WeightSigma      float64            // own weight perturbation power, with NEATConfig.SelfAdaptiveSigma (0 - not set yet)
+	Behaviour        []float64          // behaviour descriptor of the episode, used by novelty search
+	Novelty          float64            // mean distance to the nearest behaviours, with NEATConfig.NoveltyWeight
+	score            float64            // what selection ranks by: Fitness, or its blend with Novelty
+	net              *Network           // compiled network used by Forward, dropped on every change of the genome
 }
 
 type Species struct {
+	ID             int      // unique species ID, kept for the whole run
+	Representative *Genom   // new genomes are compared against it, picked from the members every generation
+	Age            int      // number of generations the species exists for
+	BestFitness    float64  // best fitness any member ever had
+	Stagnation     int      // generations since BestFitness last improved
 	Genoms         []*Genom // list of genoms within the species
 	AverageFitness float64  // average fitness within the species
 	BreedingRate   int      // how many offsprings this species is allowed to produce
 }
 
 type Population struct {
-	AllSpecies        []*Species // list of all species within the population
-	PopSize           int        // total numer of genomes within the population
-	CurrentGeneration int        // number of current generation
-	C1                float64    // constant which multiplies deltaGenes
-	C2                float64    // constant which multiplies deltaWeights
-	Threshold         float64    // threshold for speciating
+	AllSpecies        []*Species         // list of all species within the population
+	PopSize           int                // total numer of genomes within the population
+	CurrentGeneration int                // number of current generation
+	Config            NEATConfig         // hyperparameters, saved together with the population
+	NextSpeciesID     int                // ID the next new species gets
+	Threshold         float64            // threshold for speciating
+	IH                *InnovationHistory // global innovation history shared by all genomes
+	Rand              *rand.Rand         // random number generator used for breeding (must be set before breeding)
+	Novelty           *NoveltyArchive    // behaviours novelty is measured against, with NEATConfig.NoveltyWeight
 }
 
 type AIDecision struct { //przechowuje informacje o pojedyńczej decyzji podjętej przez AI (np. decyzja żeby iść w prawo)
 	Inputs      []float64        //wartości wejściowe (np. jak daleko jest enemy)
 	Outputs     []float64        //wartości wyjściowe (decyzja idź w prawo)
 	Connections []ConnectionInfo //lista wszystkich aktywnych połączeń i ich wpływu - jak do tego doszło
+	Nodes       []NodeInfo       //neurony ukryte i wyjściowe z ich funkcjami aktywacji
 }
 
 type ConnectionInfo struct { //szczegół jednego połączenia z podjętej decyzji
@@ -90,6 +123,13 @@ type ConnectionInfo struct { //szczegół jednego połączenia z podjętej decyz
 	Effect float64 //input * weight
 }
 
+type NodeInfo struct { //szczegół jednego neuronu (ukrytego albo wyjściowego) z podjętej decyzji
+	ID         int        //ID neuronu
+	Type       NodeType   //Hidden albo Output
+	Activation Activation //funkcja aktywacji neuronu
+	Value      float64    //wartość po aktywacji
+}
+
 // – – – – – – – – – – – – – – MAIN FUNCTIONALITY – – – – – – – – – – – – – – – –– – – – – – –
 
 func (genom *Genom) CreateNetwork() {
@@ -106,37 +146,29 @@ func (genom *Genom) CreateNetwork() {
 		genom.Nodes = append(genom.Nodes, &Node{ID: genom.TotalNodes, Type: Output})
 		genom.TotalNodes++
 	}
-	// adding random connections between nodes
-	rand.Seed(time.Now().UnixNano())
-	for i := 0; i < genom.NumInputs*genom.NumOutputs; i++ {
-		if rand.Float64() < genom.ConnCreationRate {
+	// adding bias node, without it outputs are stuck at activation(0) when all inputs are 0
+	genom.Nodes = append(genom.Nodes, &Node{ID: genom.TotalNodes, Type: Bias})
+	genom.TotalNodes++
+	// adding random connections between nodes (bias counts as one more input)
+	for i := 0; i < (genom.NumInputs+1)*genom.NumOutputs; i++ {
+		if genom.rng().Float64() < genom.ConnCreationRate {
 			node1, node2 := genom.randomNodes()
 			if !genom.connectionExist(node1, node2) {
-				weight := rand.Float64()*2 - 1
-				genom.addConnetion(node1, node2, weight, true)
+				genom.addConnetion(node1, node2, genom.randomWeight(), true)
 			}
 		}
 	}
 	// making sure genom has at least one connection
 	genom.forceConnection()
+	// input and output nodes have the same IDs in every genome, new hidden nodes come after them
+	genom.IH.reserveNodes(genom.TotalNodes)
 }
 
-func (genom *Genom) EvaluateFitness(score int, foodEaten, enemiesKilled, timeSurvived int, hp float64) float64 {
-	fitness := float64(foodEaten)*10 + float64(enemiesKilled)*60 + (math.Min(hp/15, 1.0))*10 + (math.Min(float64(timeSurvived)/1800.0, 1.0))*20.0
-	if foodEaten == 0 && enemiesKilled == 0 && score == 0 && timeSurvived > 1780 {
-		fitness -= 80
-	}
-	if fitness < 0 {
-		fitness = 0
-	}
-
-	genom.Fitness = fitness
-	return fitness
-}
-
-func crossover(parent1, parent2 *Genom) *Genom {
-	// creating offspring genome
-	// networks's structure is inherited from the parent with higher fitness score
+func crossover(parent1, parent2 *Genom, rng *rand.Rand, cfg *NEATConfig) *Genom {
+	// creating offspring genome, following the NEAT paper:
+	// matching genes (same innovation) are inherited randomly from either parent,
+	// disjoint and excess genes from the fitter parent - or from both if they are equally fit
+	// a gene disabled in either parent stays disabled with cfg.DisabledGeneRate chance
 
 	// making sure parent1 has higher fitness score
 	if parent2.Fitness > parent1.Fitness {
@@ -144,7 +176,13 @@ func crossover(parent1, parent2 *Genom) *Genom {
 		parent1 = parent2
 		parent2 = tmp
 	}
-	// mapping parent2 connections by their innovation score
+	equal := parent1.Fitness == parent2.Fitness
+
+	// mapping connections by their innovation score
+	parent1Map := make(map[int]Connection)
+	for _, conn := range parent1.Connections {
+		parent1Map[conn.Innovation] = conn
+	}
 	parent2Map := make(map[int]Connection)
 	for _, conn := range parent2.Connections {
 		parent2Map[conn.Innovation] = conn
@@ -155,158 +193,413 @@ func crossover(parent1, parent2 *Genom) *Genom {
 		IH:               parent1.IH,
 		NumInputs:        parent1.NumInputs,
 		NumOutputs:       parent1.NumOutputs,
-		TotalNodes:       parent1.TotalNodes,
 		ConnCreationRate: parent1.ConnCreationRate,
+		Rand:             rng,
+		Recurrent:        parent1.Recurrent,
+		WeightSigma:      parent1.WeightSigma,
 	}
 
-	// mapping nodes by their IDs to add new connections easier
-	// offspring inherits nodes from fitter parent
-	nodeMap := make(map[int]*Node)
+	// node genes of both parents by ID, the offspring gets all nodes of the fitter parent
+	// and nodes of the other one its inherited connections need
+	parent1Nodes := make(map[int]*Node)
 	for _, node := range parent1.Nodes {
-		newNode := &Node{ID: node.ID, Type: node.Type}
+		parent1Nodes[node.ID] = node
+	}
+	parent2Nodes := make(map[int]*Node)
+	for _, node := range parent2.Nodes {
+		parent2Nodes[node.ID] = node
+	}
+	nodeMap := make(map[int]*Node)
+	addNode := func(node *Node) *Node {
+		if existing, exist := nodeMap[node.ID]; exist {
+			return existing
+		}
+		newNode := &Node{ID: node.ID, Type: node.Type, Activation: node.Activation}
+		// node genes present in both parents take the activation of a random one
+		if other, exist := parent2Nodes[node.ID]; exist && parent1Nodes[node.ID] != nil && rng.IntN(2) == 1 {
+			newNode.Activation = other.Activation
+		}
 		offspring.Nodes = append(offspring.Nodes, newNode)
 		nodeMap[node.ID] = newNode
+		if node.ID >= offspring.TotalNodes {
+			offspring.TotalNodes = node.ID + 1
+		}
+		return newNode
+	}
+	for _, node := range parent1.Nodes {
+		addNode(node)
+	}
+	nodeFor := func(node *Node) *Node {
+		if parent1Nodes[node.ID] != nil {
+			return addNode(parent1Nodes[node.ID])
+		}
+		return addNode(node)
+	}
+
+	inherit := func(chosen Connection, enabled bool) {
+		inNode := nodeFor(chosen.InNode)
+		outNode := nodeFor(chosen.OutNode)
+		offspring.inheritConnection(inNode, outNode, chosen, enabled)
 	}
 
-	// offspring randomly inherits connections from either parent
 	// aligning connections by their innovation numbers
 	for _, conn1 := range parent1.Connections {
-		var chosenConn Connection
 		if conn2, exist := parent2Map[conn1.Innovation]; exist {
-			if rand.Intn(2) == 0 {
-				chosenConn = conn1
-			} else {
-				chosenConn = conn2
+			chosen := conn1
+			if rng.IntN(2) == 1 {
+				chosen = conn2
+			}
+			enabled := true
+			if !conn1.Enabled || !conn2.Enabled {
+				enabled = rng.Float64() >= cfg.DisabledGeneRate
 			}
-			// excess (unmatched) connections are inherited from fitter parent
+			inherit(chosen, enabled)
 		} else {
-			chosenConn = conn1
+			// disjoint or excess gene of the fitter parent
+			inherit(conn1, conn1.Enabled)
+		}
+	}
+	if equal {
+		// equally fit parents - disjoint and excess genes of the other parent too
+		for _, conn2 := range parent2.Connections {
+			if _, exist := parent1Map[conn2.Innovation]; !exist {
+				inherit(conn2, conn2.Enabled)
+			}
 		}
-		// adding inherited connections to the offspring's genome
-		inNode := nodeMap[chosenConn.InNode.ID]
-		outNode := nodeMap[chosenConn.OutNode.ID]
-		offspring.addConnetion(inNode, outNode, chosenConn.Weight, chosenConn.Enabled)
+	}
+	if !offspring.Recurrent {
+		// genes of two parents can close a cycle neither parent had
+		offspring.disableCycles()
 	}
 
 	return offspring
 }
 
+func (genom *Genom) inheritConnection(inNode, outNode *Node, conn Connection, enabled bool) {
+	// helper function
+	// adds a connection copied from a parent, keeping its innovation number
+	newConn := Connection{
+		InNode:     inNode,
+		OutNode:    outNode,
+		Weight:     conn.Weight,
+		Innovation: conn.Innovation,
+		Enabled:    enabled,
+	}
+	genom.Connections = append(genom.Connections, newConn)
+	outNode.IncomingConns = append(outNode.IncomingConns, newConn)
+	genom.net = nil
+}
+
+func (pop *Population) Speciate(genoms []*Genom) {
+	// assigns evaluated genomes to species
+	// species live across generations - each keeps its representative from
+	// the previous generation, and genomes which don't fit any start a new one
+	for _, species := range pop.AllSpecies {
+		if species.Representative == nil && len(species.Genoms) > 0 {
+			// populations loaded from the text dump have no representatives
+			species.Representative = species.Genoms[0]
+		}
+		species.Genoms = nil
+		species.Age++
+	}
+	for _, genom := range genoms {
+		pop.AddToSpecies(genom)
+	}
+
+	// species nobody fits into anymore are extinct
+	alive := []*Species{}
+	for _, species := range pop.AllSpecies {
+		if len(species.Genoms) > 0 {
+			alive = append(alive, species)
+		} else {
+			species.BreedingRate = 0
+		}
+	}
+	pop.AllSpecies = alive
+
+	// tracking improvement, the species with the best genom right now is never removed
+	var top *Species
+	topFitness := 0.0
+	for _, species := range pop.AllSpecies {
+		best := species.Genoms[0].Fitness
+		for _, genom := range species.Genoms {
+			best = math.Max(best, genom.Fitness)
+		}
+		if best > species.BestFitness || species.Age == 0 {
+			species.BestFitness = best
+			species.Stagnation = 0
+		} else {
+			species.Stagnation++
+		}
+		if top == nil || best > topFitness {
+			top = species
+			topFitness = best
+		}
+	}
+	if pop.Config.StagnationLimit > 0 {
+		kept := []*Species{}
+		for _, species := range pop.AllSpecies {
+			if species.Stagnation >= pop.Config.StagnationLimit && species != top {
+				species.BreedingRate = 0
+				continue
+			}
+			kept = append(kept, species)
+		}
+		pop.AllSpecies = kept
+	}
+
+	// a random member represents the species in the next generation
+	for _, species := range pop.AllSpecies {
+		species.Representative = species.Genoms[pop.rng().IntN(len(species.Genoms))]
+	}
+}
+
 func (pop *Population) AddToSpecies(genom *Genom) {
 	// adds genome to a compatible species
 	// if no match is found, creates new species and adds the genome to it
-
-	added := false
 	for _, species := range pop.AllSpecies {
-		if pop.sameSpecies(genom, species.Genoms[0]) {
+		representative := species.Representative
+		if representative == nil {
+			if len(species.Genoms) == 0 {
+				continue
+			}
+			representative = species.Genoms[0]
+		}
+		if pop.sameSpecies(genom, representative) {
 			species.Genoms = append(species.Genoms, genom)
-			added = true
 			return
 		}
 	}
 
-	if !added {
-		newSpecies := Species{}
-		newSpecies.Genoms = append(newSpecies.Genoms, genom)
-		pop.AllSpecies = append(pop.AllSpecies, &newSpecies)
+	newSpecies := &Species{
+		ID:             pop.NextSpeciesID,
+		Representative: genom,
+		Genoms:         []*Genom{genom},
 	}
+	pop.NextSpeciesID++
+	pop.AllSpecies = append(pop.AllSpecies, newSpecies)
 }
 
 func (genom *Genom) Forward(inputs []float64) ([]float64, AIDecision) {
-	fmt.Println("=== START FORWARD ===")
-	fmt.Printf("Wejścia: %v\n", inputs)
-	nodeValues := make(map[int]float64)
-	inputIndex := 0
+	// evaluates the genom and explains the decision (used by the game window)
+	// the genom is compiled on first use and again after it mutates,
+	// hot loops should Compile it once and call Activate instead
+	if genom.net == nil {
+		genom.net = genom.Compile()
+	}
+	genom.net.Activate(inputs)
+	decision := genom.net.Explain(inputs)
+	return decision.Outputs, decision
+}
+
+func (genom *Genom) ResetState() {
+	// forgets node activations remembered by a recurrent genome
+	// called before every new life of the genome
+	if genom.net != nil {
+		genom.net.Reset()
+	}
+}
+
+// ErrCycle is returned by TopologicalOrder when enabled connections form a cycle
+var ErrCycle = errors.New("genom has a cycle")
+
+func (genom *Genom) TopologicalOrder() ([]*Node, error) {
+	// returns all nodes of the genome, ordered so that every node comes
+	// after the nodes feeding it through enabled connections (Kahn's algorithm)
+	// nodes which are part of a cycle (or fed by one) are appended at the end
+	// in genome order and ErrCycle is returned along with them
+	return genom.topologicalOrder(nil)
+}
 
+func (genom *Genom) topologicalOrder(skip map[int]bool) ([]*Node, error) {
+	// helper function
+	// TopologicalOrder, leaving out connections with innovations in skip
+	inGenom := make(map[int]bool)
 	for _, node := range genom.Nodes {
-		if node.Type == 0 {
-			if inputIndex < len(inputs) {
-				nodeValues[node.ID] = inputs[inputIndex]
-				inputIndex++
-			} else {
-				nodeValues[node.ID] = 0
-			}
-		}
+		inGenom[node.ID] = true
 	}
-	// accumulating weighted sums per node
-	incomingSums := make(map[int]float64)
+	inDegree := make(map[int]int)
+	outgoing := make(map[int][]int)
 	for _, conn := range genom.Connections {
-		if !conn.Enabled {
+		if !conn.Enabled || skip[conn.Innovation] || !inGenom[conn.InNode.ID] || !inGenom[conn.OutNode.ID] {
 			continue
 		}
-		inVal := nodeValues[conn.InNode.ID]
-		incomingSums[conn.OutNode.ID] += inVal * conn.Weight
+		inDegree[conn.OutNode.ID]++
+		outgoing[conn.InNode.ID] = append(outgoing[conn.InNode.ID], conn.OutNode.ID)
 	}
-	// applying activation functions
+
+	byID := make(map[int]*Node)
+	queue := []int{}
 	for _, node := range genom.Nodes {
-		if node.Type == Hidden {
-			nodeValues[node.ID] = relu(incomingSums[node.ID])
+		byID[node.ID] = node
+		if inDegree[node.ID] == 0 {
+			queue = append(queue, node.ID)
 		}
-		if node.Type == Output {
-			nodeValues[node.ID] = sigmoid(incomingSums[node.ID])
+	}
+	order := make([]*Node, 0, len(genom.Nodes))
+	done := make(map[int]bool)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if done[id] {
+			continue
+		}
+		done[id] = true
+		order = append(order, byID[id])
+		for _, next := range outgoing[id] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
 		}
 	}
-
-	outputs := []float64{}
-	fmt.Println("Zawartość nodeValues:")
-	for k, v := range nodeValues {
-		fmt.Printf("Node %d = %.4f\n", k, v)
+	if len(order) == len(byID) {
+		return order, nil
 	}
 	for _, node := range genom.Nodes {
-		if node.Type == 2 {
-			val, exists := nodeValues[node.ID]
-			if exists {
-				outputs = append(outputs, val)
-			} else {
-				outputs = append(outputs, 0)
-				fmt.Printf("Output node %d nie ma wartości — ustawiamy 0\n", node.ID)
-			}
+		if !done[node.ID] {
+			done[node.ID] = true
+			order = append(order, node)
 		}
 	}
-	fmt.Println("=== KONIEC FORWARD ===")
-	fmt.Printf("Outputs: %v\n", outputs)
-	// zbiera informacje o wszystkich połączeniach
-	connectionInfo := make([]ConnectionInfo, 0) //pusta lista obiektow typu ConnectionInfo
-	for _, conn := range genom.Connections {    //przetwarzanie tylko aktywnych połączeń
+	return order, ErrCycle
+}
+
+func (genom *Genom) reaches(from, to *Node) bool {
+	// helper function
+	// checks if there is a path of enabled connections from one node to the other
+	// (a node always reaches itself)
+	outgoing := make(map[int][]*Node)
+	for _, conn := range genom.Connections {
 		if conn.Enabled {
-			effect := nodeValues[conn.InNode.ID] * conn.Weight      //rzeczywisty wpływ na decyzje
-			connectionInfo = append(connectionInfo, ConnectionInfo{ //zapisywanie danych połączenia
-				From:   conn.InNode.ID,
-				To:     conn.OutNode.ID,
-				Weight: conn.Weight,
-				Effect: effect,
-			})
+			outgoing[conn.InNode.ID] = append(outgoing[conn.InNode.ID], conn.OutNode)
+		}
+	}
+	visited := map[int]bool{from.ID: true}
+	stack := []*Node{from}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node.ID == to.ID {
+			return true
+		}
+		for _, next := range outgoing[node.ID] {
+			if !visited[next.ID] {
+				visited[next.ID] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
+
+func (genom *Genom) cycleEdges() map[int]bool {
+	// helper function
+	// innovations of enabled connections which close a cycle (back edges of a depth-first search,
+	// in genome order), without them the enabled connections are acyclic
+	outgoing := make(map[int][]Connection)
+	for _, conn := range genom.Connections {
+		if conn.Enabled && conn.InNode != nil && conn.OutNode != nil {
+			outgoing[conn.InNode.ID] = append(outgoing[conn.InNode.ID], conn)
+		}
+	}
+	const (
+		unvisited = iota
+		onPath
+		finished
+	)
+	state := make(map[int]int)
+	back := make(map[int]bool)
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = onPath
+		for _, conn := range outgoing[id] {
+			switch state[conn.OutNode.ID] {
+			case onPath:
+				back[conn.Innovation] = true
+			case unvisited:
+				visit(conn.OutNode.ID)
+			}
+		}
+		state[id] = finished
+	}
+	for _, node := range genom.Nodes {
+		if node != nil && state[node.ID] == unvisited {
+			visit(node.ID)
 		}
 	}
-	return outputs, AIDecision{
-		Inputs:      inputs,
-		Outputs:     outputs,
-		Connections: connectionInfo,
+	return back
+}
+
+func (genom *Genom) disableCycles() {
+	// helper function
+	// disables connections closing a cycle, so a non-recurrent genome can be evaluated in one pass
+	back := genom.cycleEdges()
+	if len(back) == 0 {
+		return
+	}
+	for i := range genom.Connections {
+		if back[genom.Connections[i].Innovation] {
+			genom.Connections[i].Enabled = false
+			syncIncoming(genom.Connections[i])
+		}
+	}
+	genom.net = nil
+}
+
+func syncIncoming(conn Connection) {
+	// helper function
+	// IncomingConns hold copies of the connections, after changing a connection
+	// in genom.Connections its copy in the target node has to be updated too
+	incoming := conn.OutNode.IncomingConns
+	for i := range incoming {
+		if incoming[i].Innovation == conn.Innovation {
+			incoming[i] = conn
+			return
+		}
 	}
 }
 
 // – – – – – – – – – – – – – – – – – MUTATIONS – – – – – – – – – – – – – – – – – – – – – – –
 
-func (genom *Genom) mutateWeight() {
+func (genom *Genom) mutateWeight(cfg *NEATConfig, sigma float64) {
 	// mutates genome by changing weights of genome's connections
-	rand.Seed(time.Now().UnixNano())
+	// every connection is mutated with cfg.WeightMutationRate chance - nudged by a random delta
+	// of the given power (or the genome's own one, if it self-adapts), or replaced by a new random weight
+	if cfg.SelfAdaptiveSigma {
+		if genom.WeightSigma <= 0 {
+			genom.WeightSigma = sigma
+		}
+		// log-normal self-adaptation, as in evolution strategies
+		tau := 1 / math.Sqrt(float64(max(len(genom.Connections), 1)))
+		genom.WeightSigma *= math.Exp(tau * genom.rng().NormFloat64())
+		sigma = genom.WeightSigma
+	}
 	for i, conn := range genom.Connections {
-		if rand.Float64() < 0.8 {
-			delta := rand.Float64()*0.4 - 0.2
-			conn.Weight += delta
+		if cfg.WeightMutationRate < 1 && genom.rng().Float64() >= cfg.WeightMutationRate {
+			continue
+		}
+		if genom.rng().Float64() < cfg.WeightPerturbRate {
+			if cfg.WeightPerturbation == GaussianPerturbation {
+				conn.Weight += genom.rng().NormFloat64() * sigma
+			} else {
+				conn.Weight += genom.rng().Float64()*(2*sigma) - sigma
+			}
 		} else {
-			conn.Weight = rand.Float64()*2.0 - 1.0
+			conn.Weight = genom.randomWeight()
 		}
+		conn.Weight = cfg.clampWeight(conn.Weight)
 		genom.Connections[i] = conn
+		syncIncoming(conn)
 	}
+	genom.net = nil
 }
 
 func (genom *Genom) mutateAddConnection() {
 	// mutates genome by adding new connection with random weight
-	rand.Seed(time.Now().UnixNano())
 	n1, n2 := genom.randomNodes()
 	if !genom.connectionExist(n1, n2) {
-		weight := rand.Float64()
-		genom.addConnetion(n1, n2, weight, true)
+		genom.addConnetion(n1, n2, genom.randomWeight(), true)
 	}
 }
 
@@ -318,173 +611,287 @@ func (genom *Genom) mutateAddNode() {
 	}
 	// takes random connection from the genome and disables it
 	// (the connection is still kept in the genome)
-	rand.Seed(time.Now().UnixNano())
-	conn := &genom.Connections[rand.Intn(len(genom.Connections))]
+	conn := &genom.Connections[genom.rng().IntN(len(genom.Connections))]
 	n1 := conn.InNode
 	n2 := conn.OutNode
+	if !genom.Recurrent && !conn.Enabled && genom.reaches(n2, n1) {
+		// the new connections would be enabled and close a cycle
+		return
+	}
 	conn.Enabled = false
+	syncIncoming(*conn)
 
 	// creates new hidden node
-	genom.TotalNodes++
-	newNode := Node{ID: genom.TotalNodes - 1, Type: Hidden}
+	// its ID comes from the innovation history, so every genome splitting this connection gets the same node
+	id := genom.IH.SplitNode(conn.Innovation, 0)
+	for nth := 1; genom.hasNode(id); nth++ {
+		// this genome split the connection before (it was disabled and got split again),
+		// genomes splitting it the same number of times share the node too
+		id = genom.IH.SplitNode(conn.Innovation, nth)
+	}
+	if id >= genom.TotalNodes {
+		genom.TotalNodes = id + 1
+	}
+	newNode := Node{ID: id, Type: Hidden}
 	// creates two new connections
 	// weighs are chosen in such way, two new connections behave in the same way as old one
 	// this way mutation is not too drastic
 	genom.addConnetion(n1, &newNode, 1.0, true)
 	genom.addConnetion(&newNode, n2, conn.Weight, true)
 	genom.Nodes = append(genom.Nodes, &newNode)
+	genom.net = nil
+}
+
+func (genom *Genom) mutateDeleteConnection() {
+	// mutates genome by removing a random connection
+	// the last connection is never removed, innovation history remembers the gene,
+	// so adding it back later gives it the same innovation number
+	if len(genom.Connections) < 2 {
+		return
+	}
+	innovation := genom.Connections[genom.rng().IntN(len(genom.Connections))].Innovation
+	genom.removeConnections(func(conn Connection) bool {
+		return conn.Innovation == innovation
+	})
+}
+
+func (genom *Genom) mutateDeleteNode() {
+	// mutates genome by removing a random hidden node along with every connection attached to it
+	candidates := []int{}
+	for i, node := range genom.Nodes {
+		if node.Type == Hidden {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	i := candidates[genom.rng().IntN(len(candidates))]
+	removed := genom.Nodes[i]
+	genom.Nodes = append(genom.Nodes[:i], genom.Nodes[i+1:]...)
+	genom.removeConnections(func(conn Connection) bool {
+		return conn.InNode == removed || conn.OutNode == removed
+	})
+
+	genom.TotalNodes = 0
+	for _, node := range genom.Nodes {
+		if node.ID >= genom.TotalNodes {
+			genom.TotalNodes = node.ID + 1
+		}
+	}
+	// making sure genom still has at least one connection
+	genom.forceConnection()
 }
 
 func (genom *Genom) mutateToggleConnection() {
 	// randomly toggles the "Enabled" state for connections
-	rand.Seed(time.Now().UnixNano())
 
 	// We randomly select one connection from the Connections list
 	if len(genom.Connections) == 0 {
 		return
 	}
 
-	conn := &genom.Connections[rand.Intn(len(genom.Connections))]
+	conn := &genom.Connections[genom.rng().IntN(len(genom.Connections))]
+	if !genom.Recurrent && !conn.Enabled && genom.reaches(conn.OutNode, conn.InNode) {
+		// enabling it would close a cycle
+		return
+	}
 
 	// We change the "Enabled" state of the connection (if it was enabled, we disable it, and vice versa)
 	conn.Enabled = !conn.Enabled
+	syncIncoming(*conn)
+	genom.net = nil
+}
+
+func (genom *Genom) Mutate(cfg *NEATConfig, sigma float64) {
+	// applies every mutation with its chance from cfg, sigma is the weight perturbation power of the generation
+	rng := genom.rng()
+	genom.mutateWeight(cfg, sigma)
+	if rng.Float64() < cfg.AddConnectionRate {
+		genom.mutateAddConnection()
+	}
+	if rng.Float64() < cfg.AddNodeRate {
+		genom.mutateAddNode()
+	}
+	if rng.Float64() < cfg.ToggleConnectionRate {
+		genom.mutateToggleConnection()
+	}
+	if rng.Float64() < cfg.ActivationRate {
+		genom.mutateActivation()
+	}
+	if rng.Float64() < cfg.DeleteConnectionRate {
+		genom.mutateDeleteConnection()
+	}
+	if rng.Float64() < cfg.DeleteNodeRate {
+		genom.mutateDeleteNode()
+	}
 }
 
 // – – – – – – – – – – – – – – SELECTION PROCESS – – – – – – – – – – – – – – – – – – – – – – –
 
-func ranked(species *Species, k int) *Genom { // wybor rodzicow - typ turniejowy
-	best := species.Genoms[rand.Intn(len(species.Genoms))]
+func ranked(candidates []*Genom, k int, rng *rand.Rand) *Genom { // wybor rodzicow - typ turniejowy
+	best := candidates[rng.IntN(len(candidates))]
 	for i := 1; i < k; i++ {
-		syzyf := species.Genoms[rand.Intn(len(species.Genoms))]
-		if syzyf.Fitness > best.Fitness {
+		syzyf := candidates[rng.IntN(len(candidates))]
+		if syzyf.score > best.score {
 			best = syzyf
 		}
 	}
 	return best
 }
 
+func (pop *Population) allocateOffspring() {
+	// sets BreedingRate of every species, so they sum up exactly to PopSize
+	// explicit fitness sharing: every genome's fitness is divided by the size of its species,
+	// so a species' share is the sum of adjusted fitness = its average fitness
+	// (fitness means the selection score here, see Population.scoreGenomes)
+	// shares are rounded with the largest remainder method
+	totalFitness := 0.0
+	for _, species := range pop.AllSpecies {
+		speciesTotal := 0.0
+		for _, g := range species.Genoms {
+			speciesTotal += g.score
+		}
+		species.AverageFitness = speciesTotal / float64(len(species.Genoms))
+		totalFitness += species.AverageFitness
+	}
+
+	remainders := make([]float64, len(pop.AllSpecies))
+	allocated := 0
+	for i, species := range pop.AllSpecies {
+		share := float64(pop.PopSize) / float64(len(pop.AllSpecies)) // nobody scored anything - equal shares
+		if totalFitness > 0 {
+			share = species.AverageFitness / totalFitness * float64(pop.PopSize)
+		}
+		species.BreedingRate = int(share)
+		remainders[i] = share - float64(species.BreedingRate)
+		allocated += species.BreedingRate
+	}
+	order := make([]int, len(pop.AllSpecies))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return remainders[order[i]] > remainders[order[j]]
+	})
+	for i := 0; allocated < pop.PopSize; i++ {
+		pop.AllSpecies[order[i%len(order)]].BreedingRate++
+		allocated++
+	}
+
+	// the species with the best genome always gets at least one slot, so its champion survives
+	var top, largest *Species
+	bestFitness := 0.0
+	for _, species := range pop.AllSpecies {
+		for _, g := range species.Genoms {
+			if top == nil || g.score > bestFitness {
+				top = species
+				bestFitness = g.score
+			}
+		}
+		if largest == nil || species.BreedingRate > largest.BreedingRate {
+			largest = species
+		}
+	}
+	if top != nil && top.BreedingRate == 0 && largest.BreedingRate > 1 {
+		top.BreedingRate++
+		largest.BreedingRate--
+	}
+}
+
 func GenerateNewPopulation(pop *Population) []*Genom {
 	fmt.Printf("[INFO] Generating new population - number of species: %d\n", len(pop.AllSpecies))
 	newGenomes := []*Genom{}
-	rand.Seed(time.Now().UnixNano())
+	rng := pop.rng()
 
 	if len(pop.AllSpecies) == 0 {
 		//fmt.Println("Brak gatunków — nie można wygenerować nowej populacji.")
 		return []*Genom{}
 	}
-	// --- ELITES ---
+
+	cfg := &pop.Config
+	sigma := cfg.weightSigma(pop.CurrentGeneration)
+	pop.scoreGenomes()
+	pop.allocateOffspring()
+
+	// best genomes of the whole population are copied unchanged, using slots of their species
 	allGenomes := AllGenomesFromPopulation(pop)
 	sort.SliceStable(allGenomes, func(i, j int) bool {
 		return allGenomes[i].Fitness > allGenomes[j].Fitness
 	})
-
-	numElites := 2
-	if len(allGenomes) < numElites {
-		numElites = len(allGenomes)
+	globalElites := make(map[*Genom]bool)
+	for i := 0; i < cfg.Elites && i < len(allGenomes); i++ {
+		globalElites[allGenomes[i]] = true
 	}
 
-	eliteClones := []*Genom{}
-	for i := 0; i < numElites; i++ {
-		clone := CloneGenom(allGenomes[i])
-		eliteClones = append(eliteClones, clone)
-	}
-	newGenomes = append(newGenomes, eliteClones...)
-	//here we calculate the average fitness for species n how many offsprings a species can have
-	totalFitness := 0.0
 	for _, species := range pop.AllSpecies {
-		speciesTotal := 0.0
-		for _, g := range species.Genoms {
-			speciesTotal += g.Fitness
-
+		if species.BreedingRate == 0 {
+			continue
 		}
-		species.AverageFitness = speciesTotal / float64(len(species.Genoms))
-		totalFitness += species.AverageFitness
-	}
-
-	for _, species := range pop.AllSpecies {
-		offspringCount := int((species.AverageFitness / totalFitness) * float64(pop.PopSize))
-		for i := 0; i < offspringCount && len(newGenomes) < pop.PopSize; i++ {
-			if len(species.Genoms) == 0 {
-				continue
-			}
-			parent1 := ranked(species, 3) // 3 means we choosin 3 candidates
-			parent2 := ranked(species, 3)
-			child := crossover(parent1, parent2)
-
-			// Mutations in offsprings
-			child.mutateWeight()
-			if rand.Float64() < 0.8 {
-				child.mutateAddConnection()
-			}
-			if rand.Float64() < 0.35 {
-				child.mutateAddNode()
+		members := append([]*Genom{}, species.Genoms...)
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].score > members[j].score
+		})
+
+		// --- ELITES ---
+		elites := 0
+		for _, genom := range members {
+			if globalElites[genom] {
+				elites++
 			}
-			if rand.Float64() < 0.1 {
-				child.mutateToggleConnection()
-			}
-
-			newGenomes = append(newGenomes, child)
 		}
-	}
-
-	// if somehow well end up with less offsprings we will add randoms from the best pokemons
-	for len(newGenomes) < pop.PopSize {
-		if len(pop.AllSpecies) == 0 {
-			fmt.Println("Brak dostępnych gatunków przy tworzeniu nowej generacji.")
-			break
+		if elites < cfg.SpeciesElites {
+			elites = cfg.SpeciesElites
 		}
-		bestSpecies := pop.AllSpecies[rand.Intn(len(pop.AllSpecies))]
-		if len(bestSpecies.Genoms) == 0 {
-			continue
+		if elites > species.BreedingRate {
+			elites = species.BreedingRate
 		}
-		parent := bestSpecies.Genoms[rand.Intn(len(bestSpecies.Genoms))]
-
-		//better version of copy - should work
-		newGen := &Genom{
-			NumInputs:        parent.NumInputs,
-			NumOutputs:       parent.NumOutputs,
-			ConnCreationRate: parent.ConnCreationRate,
-			IH:               parent.IH,
-			TotalNodes:       parent.TotalNodes,
+		if elites > len(members) {
+			elites = len(members)
 		}
-
-		nodeMap := make(map[int]*Node)
-		for _, node := range parent.Nodes {
-			newNode := &Node{
-				ID:   node.ID,
-				Type: node.Type,
-			}
-			newGen.Nodes = append(newGen.Nodes, newNode)
-			nodeMap[node.ID] = newNode
+		for i := 0; i < elites; i++ {
+			clone := CloneGenom(members[i])
+			clone.Rand = pop.Rand
+			newGenomes = append(newGenomes, clone)
 		}
 
-		for _, conn := range parent.Connections {
-			newConn := Connection{
-				InNode:     nodeMap[conn.InNode.ID],
-				OutNode:    nodeMap[conn.OutNode.ID],
-				Weight:     conn.Weight,
-				Innovation: conn.Innovation,
-				Enabled:    conn.Enabled,
-			}
-			newGen.Connections = append(newGen.Connections, newConn)
-			nodeMap[conn.OutNode.ID].IncomingConns = append(nodeMap[conn.OutNode.ID].IncomingConns, newConn)
+		// --- OFFSPRING ---
+		survivors := int(math.Ceil(cfg.SurvivalThreshold * float64(len(members))))
+		if survivors < 1 {
+			survivors = 1
 		}
+		parents := members[:survivors]
+		for i := elites; i < species.BreedingRate; i++ {
+			parent1 := ranked(parents, cfg.TournamentSize, rng)
+			var parent2 *Genom
+			if len(pop.AllSpecies) > 1 && rng.Float64() < cfg.InterspeciesMatingRate {
+				// interspecies mating - the second parent comes from a random other species
+				other := pop.AllSpecies[rng.IntN(len(pop.AllSpecies)-1)]
+				if other == species {
+					other = pop.AllSpecies[len(pop.AllSpecies)-1]
+				}
+				parent2 = ranked(other.Genoms, cfg.TournamentSize, rng)
+			} else {
+				parent2 = ranked(parents, cfg.TournamentSize, rng)
+			}
+			child := crossover(parent1, parent2, rng, cfg)
+			child.Mutate(cfg, sigma)
 
-		newGenomes = append(newGenomes, newGen)
+			newGenomes = append(newGenomes, child)
+		}
 	}
-	targetSpecies := 8
-	adjustStep := 0.1
-	if len(pop.AllSpecies) < targetSpecies {
-		pop.Threshold -= adjustStep
-	} else if len(pop.AllSpecies) > targetSpecies {
-		pop.Threshold += adjustStep
+
+	if len(pop.AllSpecies) < cfg.TargetSpecies {
+		pop.Threshold -= cfg.ThresholdStep
+	} else if len(pop.AllSpecies) > cfg.TargetSpecies {
+		pop.Threshold += cfg.ThresholdStep
 	}
 
-	if pop.Threshold < 0.5 {
-		pop.Threshold = 0.5
+	if pop.Threshold < cfg.MinThreshold {
+		pop.Threshold = cfg.MinThreshold
 	}
-	if pop.Threshold > 10.0 {
-		pop.Threshold = 10.0
+	if pop.Threshold > cfg.MaxThreshold {
+		pop.Threshold = cfg.MaxThreshold
 	}
 	fmt.Printf("[ADAPT] New threshold: %.2f\n", pop.Threshold)
 	fmt.Printf("[INFO] New population – number of genoms: %d\n", len(newGenomes))
@@ -493,6 +900,50 @@ func GenerateNewPopulation(pop *Population) []*Genom {
 
 // – – – – – – – – – – – – – – UTILITY FUNCTIONS – – – – – – – – – – – – – – – – – – – – – – –
 
+func (genom *Genom) rng() *rand.Rand {
+	// helper function
+	// returns genome's random number generator, it has to be injected before the genome mutates
+	if genom.Rand == nil {
+		panic("data: genom has no random number generator")
+	}
+	return genom.Rand
+}
+
+func (pop *Population) innovationHistory() *InnovationHistory {
+	// helper function
+	// returns innovation history of the population,
+	// populations built by hand only have it in their genomes
+	if pop.IH != nil {
+		return pop.IH
+	}
+	for _, genom := range AllGenomesFromPopulation(pop) {
+		if genom.IH != nil {
+			return genom.IH
+		}
+	}
+	return nil
+}
+
+func (pop *Population) reserveNodeIDs() {
+	// helper function
+	// after loading, new hidden nodes must not reuse IDs of nodes which already exist
+	ih := pop.innovationHistory()
+	for _, genom := range AllGenomesFromPopulation(pop) {
+		for _, node := range genom.Nodes {
+			ih.reserveNodes(node.ID + 1)
+		}
+	}
+}
+
+func (pop *Population) rng() *rand.Rand {
+	// helper function
+	// returns population's random number generator, it has to be injected before breeding
+	if pop.Rand == nil {
+		panic("data: population has no random number generator")
+	}
+	return pop.Rand
+}
+
 func (ih *InnovationHistory) GetInnovation(inNode, outNode *Node) int {
 	// given nodes, returns innovation nubmer of the connection between them
 	if ih.History == nil {
@@ -509,6 +960,47 @@ func (ih *InnovationHistory) GetInnovation(inNode, outNode *Node) int {
 	return ih.History[key]
 }
 
+func (ih *InnovationHistory) SplitNode(splitInnovation, nth int) int {
+	// given innovation number of a split connection and how many times it was split before,
+	// returns ID of the hidden node put in its place
+	if ih.Splits == nil {
+		ih.Splits = make(map[SplitKey]int)
+	}
+	key := SplitKey{connection: splitInnovation, nth: nth}
+	if id, exist := ih.Splits[key]; exist {
+		return id
+	}
+	id := ih.NewNodeID()
+	ih.Splits[key] = id
+	return id
+}
+
+func (ih *InnovationHistory) NewNodeID() int {
+	// returns node ID which no genome uses yet
+	id := ih.NodeCounter
+	ih.NodeCounter++
+	return id
+}
+
+func (ih *InnovationHistory) reserveNodes(totalNodes int) {
+	// helper function
+	// makes sure node IDs below totalNodes are never given to new nodes
+	if ih.NodeCounter < totalNodes {
+		ih.NodeCounter = totalNodes
+	}
+}
+
+func (genom *Genom) hasNode(id int) bool {
+	// helper function
+	// checks, if the genome has a node with given ID
+	for _, node := range genom.Nodes {
+		if node.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (genom *Genom) addConnetion(node1, node2 *Node, weight float64, enabled bool) {
 	// helper function
 	// given nodes, weight and enabled, adds specific connection to the genome
@@ -523,7 +1015,32 @@ func (genom *Genom) addConnetion(node1, node2 *Node, weight float64, enabled boo
 	genom.Connections = append(genom.Connections, newConn)
 	// adds connection to the node right away
 	node2.IncomingConns = append(node2.IncomingConns, newConn)
+	// the compiled network doesn't know about the new connection
+	genom.net = nil
+}
+
+func (genom *Genom) removeConnections(remove func(Connection) bool) {
+	// helper function
+	// removes matching connections from the genome and from IncomingConns of the nodes they enter
+	kept := make([]Connection, 0, len(genom.Connections))
+	for _, conn := range genom.Connections {
+		if !remove(conn) {
+			kept = append(kept, conn)
+		}
+	}
+	genom.Connections = kept
+	for _, node := range genom.Nodes {
+		incoming := node.IncomingConns[:0:0]
+		for _, conn := range node.IncomingConns {
+			if !remove(conn) {
+				incoming = append(incoming, conn)
+			}
+		}
+		node.IncomingConns = incoming
+	}
+	genom.net = nil
 }
+
 func (genom *Genom) connectionExist(inNode, outNode *Node) bool {
 	// helper function
 	// checks, if the connection already exist in the genome
@@ -538,98 +1055,127 @@ func (genom *Genom) connectionExist(inNode, outNode *Node) bool {
 func (genom *Genom) forceConnection() {
 	// forces genome to have at least one connection
 	if len(genom.Connections) == 0 {
-		rand.Seed(time.Now().UnixNano())
 		n1, n2 := genom.randomNodes()
-		weight := rand.Float64()
-		genom.addConnetion(n1, n2, weight, true)
+		genom.addConnetion(n1, n2, genom.randomWeight(), true)
 	}
 }
 
+func (genom *Genom) randomWeight() float64 {
+	// helper function
+	// weight of a new or replaced connection, uniform in [-1, 1)
+	return genom.rng().Float64()*2 - 1
+}
+
 func (genom *Genom) randomNodes() (*Node, *Node) {
 	// helper function
 	// returns two nodes from the genome, which can make connection n1 –> n2
-	rand.Seed(time.Now().UnixNano())
-	n1 := genom.Nodes[rand.Intn(len(genom.Nodes))]
-	n2 := genom.Nodes[rand.Intn(len(genom.Nodes))]
-
-	// makes sure the connection will be made in the valid direction
-	for n1.Type == Hidden && n2.Type == Hidden {
-		n2 = genom.Nodes[rand.Intn(len(genom.Nodes))]
-	}
-
-	for n2.Type == Input {
-		n2 = genom.Nodes[rand.Intn(len(genom.Nodes))]
+	// both nodes are drawn again until every rule holds:
+	// inputs and the bias are never targets, and unless the genome is recurrent,
+	// outputs are never sources and the connection can't close a cycle (self-loops included)
+	for attempt := 0; attempt < 100; attempt++ {
+		n1 := genom.Nodes[genom.rng().IntN(len(genom.Nodes))]
+		n2 := genom.Nodes[genom.rng().IntN(len(genom.Nodes))]
+		if genom.validConnection(n1, n2) {
+			return n1, n2
+		}
 	}
-
-	for n1.Type == Output {
-		n1 = genom.Nodes[rand.Intn(len(genom.Nodes))]
+	// an input -> output connection is always valid
+	sources, targets := []*Node{}, []*Node{}
+	for _, node := range genom.Nodes {
+		switch node.Type {
+		case Input, Bias:
+			sources = append(sources, node)
+		case Output:
+			targets = append(targets, node)
+		}
 	}
-
-	return n1, n2
+	return sources[genom.rng().IntN(len(sources))], targets[genom.rng().IntN(len(targets))]
 }
 
-func deltaGenes(genom1, genom2 *Genom) float64 {
-	// returns normalized number of disjoint genes (connections which don't match)
-	disjointGenes := 0.0
-
-	// number of genes in longer genome for normalization
-	var longerGenome int
-	if len(genom1.Connections) > len(genom2.Connections) {
-		longerGenome = len(genom1.Connections)
-	} else {
-		longerGenome = len(genom2.Connections)
-	}
-
-	// creating maps of connections for easier comparison
-	genom1Map := make(map[int]bool)
-	for _, conn := range genom1.Connections {
-		genom1Map[conn.Innovation] = true
+func (genom *Genom) validConnection(n1, n2 *Node) bool {
+	// helper function
+	// checks if connection n1 -> n2 may be added to the genome
+	if n2.Type == Input || n2.Type == Bias {
+		return false
 	}
-	genom2Map := make(map[int]bool)
-	for _, conn := range genom2.Connections {
-		genom2Map[conn.Innovation] = true
+	if genom.Recurrent {
+		// recurrent genomes may connect any node to any non-input node,
+		// including hidden -> hidden, output -> hidden and self-loops
+		return true
 	}
+	return n1.Type != Output && !genom.reaches(n2, n1)
+}
 
-	// comparing genomes
+func geneDifferences(genom1, genom2 *Genom) (excess, disjoint int, weightDiff float64) {
+	// compares genomes gene by gene, aligned by innovation numbers
+	// excess genes are the unmatched ones beyond the other genome's highest innovation,
+	// disjoint genes are the unmatched ones within it
+	// weightDiff is the mean absolute weight difference of matching genes (0 if there are none)
+	genom1Map := make(map[int]Connection)
+	max1 := 0
 	for _, conn := range genom1.Connections {
-		if _, exists := genom2Map[conn.Innovation]; !exists {
-			disjointGenes++
+		genom1Map[conn.Innovation] = conn
+		if conn.Innovation > max1 {
+			max1 = conn.Innovation
 		}
 	}
-	for _, conn := range genom2.Connections {
-		if _, exists := genom1Map[conn.Innovation]; !exists {
-			disjointGenes++
-		}
-	}
-	return disjointGenes / float64(longerGenome)
-}
-
-func deltaWeights(genom1, genom2 *Genom) float64 {
-	// calcutates average weight difference between connections of genomes
-	differences := 0.0
-	numMatches := 0.0
-
 	genom2Map := make(map[int]Connection)
+	max2 := 0
 	for _, conn := range genom2.Connections {
 		genom2Map[conn.Innovation] = conn
+		if conn.Innovation > max2 {
+			max2 = conn.Innovation
+		}
 	}
+
+	matching := 0
 	for _, conn1 := range genom1.Connections {
-		if conn2, exist := genom2Map[conn1.Innovation]; exist {
-			differences += (conn1.Weight - conn2.Weight)
-			numMatches++
+		if conn2, exists := genom2Map[conn1.Innovation]; exists {
+			weightDiff += math.Abs(conn1.Weight - conn2.Weight)
+			matching++
+		} else if conn1.Innovation > max2 {
+			excess++
+		} else {
+			disjoint++
+		}
+	}
+	for _, conn2 := range genom2.Connections {
+		if _, exists := genom1Map[conn2.Innovation]; exists {
+			continue
+		}
+		if conn2.Innovation > max1 {
+			excess++
+		} else {
+			disjoint++
 		}
 	}
-	return math.Abs(differences / numMatches)
+	if matching > 0 {
+		weightDiff /= float64(matching)
+	}
+	return excess, disjoint, weightDiff
+}
+
+func (pop *Population) Compatibility(genom1, genom2 *Genom) float64 {
+	// compatibility distance from the NEAT paper:
+	// δ = C1·E/N + C2·D/N + C3·W̄
+	// N is the number of genes of the larger genome, or 1 for genomes
+	// smaller than Config.SmallGenomeGenes (their few differences shouldn't be scaled down)
+	excess, disjoint, weightDiff := geneDifferences(genom1, genom2)
+	n := len(genom1.Connections)
+	if len(genom2.Connections) > n {
+		n = len(genom2.Connections)
+	}
+	if n < pop.Config.SmallGenomeGenes || n == 0 {
+		n = 1
+	}
+	cfg := &pop.Config
+	return cfg.C1*float64(excess)/float64(n) + cfg.C2*float64(disjoint)/float64(n) + cfg.C3*weightDiff
 }
 
 func (pop *Population) sameSpecies(genom1, genom2 *Genom) bool {
 	// checks if two genomes are within same species
-	// C1, C2 and Threshold are constants, which need to be tuned experimentally
-	dg := deltaGenes(genom1, genom2)
-	dw := deltaWeights(genom1, genom2)
-	delta := pop.C1*dg + pop.C2*dw
-
-	return delta < pop.Threshold
+	// C1, C2, C3 (in Config) and Threshold need to be tuned experimentally
+	return pop.Compatibility(genom1, genom2) < pop.Threshold
 }
 
 func relu(x float64) float64 { //funkcja aktywacji relu - wywolywana w funkcji forward
@@ -645,23 +1191,33 @@ func sigmoid(x float64) float64 {
 }
 
 func SavePopulationToFile(pop *Population, generation int) error { //funkcja testowa sprawdzajaca dzialanie NEAT
-	os.MkdirAll("generations", os.ModePerm)
-	filename := fmt.Sprintf("generations/generation_%d.txt", generation)
+	// human readable dump of the population, export only
+	// it loses innovation numbers and NEAT state - use SavePopulationJSON to continue evolution later
+	return SavePopulationToDir("generations", pop, generation)
+}
+
+func SavePopulationToDir(dir string, pop *Population, generation int) error {
+	// same as SavePopulationToFile, but lets the caller choose the directory
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("generation_%d.txt", generation))
 	file, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	for speciesIdx, species := range pop.AllSpecies {
-		fmt.Fprintf(file, "=== SPECIES %d ===\n", speciesIdx)
+	for _, species := range pop.AllSpecies {
+		fmt.Fprintf(file, "=== SPECIES %d ===\n", species.ID)
 		fmt.Fprintf(file, "Average Fitness: %.2f\n", species.AverageFitness)
+		fmt.Fprintf(file, "Age: %d, Best Fitness: %.2f, Stagnation: %d\n", species.Age, species.BestFitness, species.Stagnation)
 		for genomIdx, genom := range species.Genoms {
 			fmt.Fprintf(file, "\n--- Genom %d (Fitness: %.2f) ---\n", genomIdx, genom.Fitness)
-			fmt.Fprintf(file, "Belongs to Species: %d\n", speciesIdx)
+			fmt.Fprintf(file, "Belongs to Species: %d\n", species.ID)
 			fmt.Fprintln(file, "Nodes:")
 			for _, node := range genom.Nodes {
-				fmt.Fprintf(file, "  Node ID: %d, Type: %s\n", node.ID, node.Type.String())
+				fmt.Fprintf(file, "  Node ID: %d, Type: %s, Activation: %s\n", node.ID, node.Type.String(), node.Activation.String())
 			}
 
 			fmt.Fprintln(file, "Connections:")
@@ -691,6 +1247,10 @@ func AllGenomesFromPopulation(pop *Population) []*Genom {
 }
 
 func AppendBestFitnessLog(generation int, population []*Genom) error {
+	return AppendBestFitnessLogTo("best_fitness_log.csv", generation, population)
+}
+
+func AppendBestFitnessLogTo(filename string, generation int, population []*Genom) error {
 	if len(population) == 0 {
 		return nil
 	}
@@ -711,7 +1271,7 @@ func AppendBestFitnessLog(generation int, population []*Genom) error {
 	avgFitness := totalFitness / float64(len(population))
 
 	// Zapis do pliku CSV
-	file, err := os.OpenFile("best_fitness_log.csv", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
@@ -738,13 +1298,18 @@ func CloneGenom(original *Genom) *Genom {
 		IH:               original.IH,
 		TotalNodes:       original.TotalNodes,
 		Fitness:          original.Fitness,
+		Stats:            original.Stats,
+		Rand:             original.Rand,
+		Recurrent:        original.Recurrent,
+		WeightSigma:      original.WeightSigma,
 	}
 
 	nodeMap := make(map[int]*Node)
 	for _, node := range original.Nodes {
 		newNode := &Node{
-			ID:   node.ID,
-			Type: node.Type,
+			ID:         node.ID,
+			Type:       node.Type,
+			Activation: node.Activation,
 		}
 		newGen.Nodes = append(newGen.Nodes, newNode)
 		nodeMap[node.ID] = newNode
@@ -775,6 +1340,8 @@ func (t NodeType) String() string {
 		return "Input"
 	case Output:
 		return "Output"
+	case Bias:
+		return "Bias"
 	default:
 		return "Hidden"
 	}
@@ -817,9 +1384,9 @@ func LoadPopulationFromFile(filename string, ih *InnovationHistory) (*Population
 
 	pop := &Population{
 		AllSpecies: []*Species{},
-		C1:         1.0,
-		C2:         0.5,
-		Threshold:  3.0,
+		Config:     DefaultNEATConfig(),
+		Threshold:  DefaultNEATConfig().InitialThreshold,
+		IH:         ih,
 	}
 	speciesMap := map[int]*Species{}
 	var currentGenom *Genom
@@ -835,7 +1402,7 @@ func LoadPopulationFromFile(filename string, ih *InnovationHistory) (*Population
 		if strings.HasPrefix(line, "--- Genom") {
 			if currentGenom != nil {
 				if speciesMap[currentSpeciesID] == nil {
-					speciesMap[currentSpeciesID] = &Species{}
+					speciesMap[currentSpeciesID] = &Species{ID: currentSpeciesID}
 				}
 				speciesMap[currentSpeciesID].Genoms = append(speciesMap[currentSpeciesID].Genoms, currentGenom)
 				pop.PopSize++
@@ -895,15 +1462,26 @@ func LoadPopulationFromFile(filename string, ih *InnovationHistory) (*Population
 			case "Type: Output":
 				t = Output
 				currentGenom.NumOutputs++
+			case "Type: Bias":
+				t = Bias
 			default:
 				fmt.Printf("%s", typeStr)
 				t = Hidden
 			}
 
 			node := &Node{ID: id, Type: t}
+			// files saved before activations were genes don't have this part
+			if len(parts) > 2 {
+				actStr := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(parts[2]), "Activation:"))
+				if err := node.Activation.UnmarshalText([]byte(actStr)); err != nil {
+					return nil, err
+				}
+			}
 			currentGenom.Nodes = append(currentGenom.Nodes, node)
 			nodeMap[id] = node
-			currentGenom.TotalNodes++
+			if id >= currentGenom.TotalNodes {
+				currentGenom.TotalNodes = id + 1
+			}
 			continue
 		}
 
@@ -916,6 +1494,9 @@ func LoadPopulationFromFile(filename string, ih *InnovationHistory) (*Population
 
 			in := nodeMap[inID]
 			out := nodeMap[outID]
+			if in == nil || out == nil {
+				return nil, fmt.Errorf("%s: connection %d -> %d points at a missing node", filename, inID, outID)
+			}
 			conn := Connection{
 				InNode:  in,
 				OutNode: out,
@@ -931,16 +1512,32 @@ func LoadPopulationFromFile(filename string, ih *InnovationHistory) (*Population
 	// Dodanie ostatniego genomu
 	if currentGenom != nil {
 		if speciesMap[currentSpeciesID] == nil {
-			speciesMap[currentSpeciesID] = &Species{}
+			speciesMap[currentSpeciesID] = &Species{ID: currentSpeciesID}
 		}
 		speciesMap[currentSpeciesID].Genoms = append(speciesMap[currentSpeciesID].Genoms, currentGenom)
 		pop.PopSize++
 	}
 
-	// Przepisanie mapy do listy
-	for _, species := range speciesMap {
+	// Przepisanie mapy do listy (w kolejności ID, żeby wczytanie było powtarzalne)
+	speciesIDs := []int{}
+	for id := range speciesMap {
+		speciesIDs = append(speciesIDs, id)
+	}
+	sort.Ints(speciesIDs)
+	for _, id := range speciesIDs {
+		species := speciesMap[id]
+		for i, genom := range species.Genoms {
+			// older generations could have cycles, Forward evaluates nodes in one pass
+			genom.disableCycles()
+			if err := genom.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: species %d, genom %d: %w", filename, id, i, err)
+			}
+		}
+		species.Representative = species.Genoms[0]
 		pop.AllSpecies = append(pop.AllSpecies, species)
+		pop.NextSpeciesID = id + 1
 	}
+	pop.reserveNodeIDs()
 
 	return pop, scanner.Err()
 }
@@ -950,9 +1547,10 @@ func PrintPopulation(pop *Population) {
 	fmt.Printf("Total Genomes: %d\n", pop.PopSize)
 	fmt.Printf("Species Count: %d\n", len(pop.AllSpecies))
 
-	for i, species := range pop.AllSpecies {
-		fmt.Printf("\n— Species %d —\n", i)
-		fmt.Printf("  Genomes in species: %d\n", len(species.Genoms))
+	for _, species := range pop.AllSpecies {
+		fmt.Printf("\n— Species %d —\n", species.ID)
+		fmt.Printf("  Genomes in species: %d | Age: %d | Best Fitness: %.2f | Stagnation: %d\n",
+			len(species.Genoms), species.Age, species.BestFitness, species.Stagnation)
 		for j, g := range species.Genoms {
 			fmt.Printf("  [Genom %d] Fitness: %.2f | Inputs: %d | Outputs: %d | Total Nodes: %d | Connections: %d\n",
 				j, g.Fitness, g.NumInputs, g.NumOutputs, g.TotalNodes, len(g.Connections))
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// – – – – – – – – – – – – – – – – – GRAPHVIZ EXPORT – – – – – – – – – – – – – – – – – – – – –
+// the generation_N.txt dumps list every connection, which stops being readable
+// after a few dozen hidden nodes, a drawn graph shows the structure at a glance
+
+// CPPNInputNames and CPPNOutputNames label the nodes of HyperNEAT genomes, see Substrate.Build
+var (
+	CPPNInputNames  = []string{"x1", "y1", "z1", "x2", "y2"}
+	CPPNOutputNames = []string{"weight", "bias"}
+)
+
+// weights drawn with the thickest line
+const dotMaxWeight = 4.0
+
+func (genom *Genom) ToDOT(inputNames, outputNames []string) string {
+	// describes the genome as a Graphviz graph, inputs on the left, outputs on the right
+	// inputs and outputs are labelled with the given names, in node order (missing ones get their ID)
+	// edge thickness is the weight's size, green edges are positive, red negative, disabled genes are dashed
+	var b strings.Builder
+	b.WriteString("digraph genom {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("\tedge [arrowsize=0.6];\n")
+
+	var inputs, outputs []string
+	nInputs, nOutputs := 0, 0
+	for _, node := range genom.Nodes {
+		id := fmt.Sprintf("n%d", node.ID)
+		switch node.Type {
+		case Input:
+			label := dotName(inputNames, nInputs, "in", node.ID)
+			nInputs++
+			fmt.Fprintf(&b, "\t%s [label=%q, shape=box, style=filled, fillcolor=\"#cfe2f3\"];\n", id, label)
+			inputs = append(inputs, id)
+		case Bias:
+			fmt.Fprintf(&b, "\t%s [label=\"bias\", shape=box, style=filled, fillcolor=\"#eeeeee\"];\n", id)
+			inputs = append(inputs, id)
+		case Output:
+			label := dotName(outputNames, nOutputs, "out", node.ID)
+			nOutputs++
+			fmt.Fprintf(&b, "\t%s [label=%q, shape=doublecircle, style=filled, fillcolor=\"#fce5cd\"];\n", id, label+"\n"+node.activation().String())
+			outputs = append(outputs, id)
+		default:
+			fmt.Fprintf(&b, "\t%s [label=%q, shape=circle];\n", id, fmt.Sprintf("%d\n%s", node.ID, node.activation()))
+		}
+	}
+	if len(inputs) > 0 {
+		fmt.Fprintf(&b, "\t{ rank=source; %s; }\n", strings.Join(inputs, "; "))
+	}
+	if len(outputs) > 0 {
+		fmt.Fprintf(&b, "\t{ rank=sink; %s; }\n", strings.Join(outputs, "; "))
+	}
+
+	for _, conn := range genom.Connections {
+		strength := math.Min(math.Abs(conn.Weight), dotMaxWeight) / dotMaxWeight
+		attrs := fmt.Sprintf("penwidth=%.2f, color=%q, tooltip=\"innovation %d, weight %.3f\"",
+			0.5+3.5*strength, dotColor(conn.Weight, strength), conn.Innovation, conn.Weight)
+		if !conn.Enabled {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\tn%d -> n%d [%s];\n", conn.InNode.ID, conn.OutNode.ID, attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotName(names []string, i int, prefix string, id int) string {
+	// helper function
+	if i < len(names) && names[i] != "" {
+		return names[i]
+	}
+	return fmt.Sprintf("%s %d", prefix, id)
+}
+
+func dotColor(weight, strength float64) string {
+	// helper function
+	// green for positive, red for negative weights, weak ones fade to grey
+	shade := int(200 - 170*strength)
+	if weight >= 0 {
+		return fmt.Sprintf("#%02x%02x%02x", shade, 200-int(40*strength), shade)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", 200+int(30*strength), shade, shade)
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestToDOT(t *testing.T) {
+	genom := validGenom()
+	genom.Connections[0].Enabled = false
+	genom.Connections[1].Weight = -10
+	dot := genom.ToDOT([]string{"score", "hp"}, []string{"dx", "dy"})
+
+	for _, want := range []string{
+		"digraph genom {",
+		`n0 [label="score"`,
+		`n1 [label="hp"`,
+		`n2 [label="in 2"`, // no name given
+		fmt.Sprintf(`n%d [label="dx\nsigmoid"`, genom.NumInputs),
+		fmt.Sprintf(`n%d [label="bias"`, genom.NumInputs+genom.NumOutputs),
+		"rank=source",
+		"rank=sink",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("DOT output has no %q:\n%s", want, dot)
+		}
+	}
+	for _, node := range genom.Nodes {
+		if node.Type == Hidden && !strings.Contains(dot, fmt.Sprintf("n%d [label=\"%d\\n", node.ID, node.ID)) {
+			t.Fatalf("hidden node %d is missing:\n%s", node.ID, dot)
+		}
+	}
+
+	if !strings.Contains(dot, "style=dashed") {
+		t.Fatalf("the disabled gene isn't drawn:\n%s", dot)
+	}
+	edges := 0
+	for _, line := range strings.Split(dot, "\n") {
+		if !strings.Contains(line, "->") {
+			continue
+		}
+		edges++
+		for _, conn := range genom.Connections {
+			if strings.Contains(line, fmt.Sprintf("innovation %d,", conn.Innovation)) && conn.Enabled == strings.Contains(line, "style=dashed") {
+				t.Fatalf("only disabled genes should be dashed: %s", line)
+			}
+		}
+		if strings.Contains(line, fmt.Sprintf("innovation %d,", genom.Connections[1].Innovation)) &&
+			!strings.Contains(line, "penwidth=4.00") {
+			t.Fatalf("the strongest weight should have the thickest edge: %s", line)
+		}
+	}
+	if edges != len(genom.Connections) {
+		t.Fatalf("%d edges, want %d", edges, len(genom.Connections))
+	}
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// – – – – – – – – – – – – – – – – FITNESS FUNCTIONS – – – – – – – – – – – – – – – – – – – –
+// the world only records what a genom did (EpisodeStats), a FitnessFunc decides what it was worth
+// new hypotheses are tested by adding a function to FitnessFuncs and picking it by name
+
+// EpisodeStats is what a genom did during the episode its Fitness was computed from
+type EpisodeStats struct {
+	Seed           uint64 `json:"seed"`            // world seed, replaying it gives the same episode
+	LifetimeFrames int    `json:"lifetime_frames"` // how long the genom was allowed to live
+	FramesLived    int    `json:"frames_lived"`
+	Died           bool   `json:"died"` // killed or starved before the lifetime was over
+
+	// counters the world resets on every evolution (what the original formula was built on)
+	Score         int     `json:"score"` // never reset
+	FoodEaten     int     `json:"food_eaten"`
+	EnemiesKilled int     `json:"enemies_killed"`
+	TimeSurvived  int     `json:"time_survived"` // frames with calories counting down
+	HP            float64 `json:"hp"`            // at the end of the episode
+
+	// whole episode
+	TotalFoodEaten     int     `json:"total_food_eaten"`
+	TotalEnemiesKilled int     `json:"total_enemies_killed"`
+	VitaminsTaken      int     `json:"vitamins_taken"`
+	Evolutions         int     `json:"evolutions"`
+	DamageDealt        float64 `json:"damage_dealt"`
+	DamageTaken        float64 `json:"damage_taken"`
+	Distance           float64 `json:"distance"` // travelled by the player, in pixels
+	MeanCalories       float64 `json:"mean_calories"`
+	MinCalories        float64 `json:"min_calories"`
+	FinalCalories      float64 `json:"final_calories"`
+}
+
+// FitnessFunc scores an episode, higher is better
+type FitnessFunc interface {
+	Fitness(stats EpisodeStats) float64
+}
+
+// FitnessFuncOf lets a plain function be used as a FitnessFunc
+type FitnessFuncOf func(stats EpisodeStats) float64
+
+func (f FitnessFuncOf) Fitness(stats EpisodeStats) float64 {
+	return f(stats)
+}
+
+// DefaultFitness is the name of the fitness function used when a config doesn't pick one
+const DefaultFitness = "legacy"
+
+// FitnessFuncs are the built-in fitness functions, by name
+var FitnessFuncs = map[string]FitnessFunc{
+	// the formula EVA was always trained with:
+	// 10 per food, 60 per kill, up to 10 for hp and 20 for surviving, -80 for idling the whole life
+	"legacy": FitnessFuncOf(func(s EpisodeStats) float64 {
+		fitness := float64(s.FoodEaten)*10 + float64(s.EnemiesKilled)*60 + (math.Min(s.HP/15, 1.0))*10 + (math.Min(float64(s.TimeSurvived)/1800.0, 1.0))*20.0
+		if s.FoodEaten == 0 && s.EnemiesKilled == 0 && s.Score == 0 && s.TimeSurvived > 1780 {
+			fitness -= 80
+		}
+		return math.Max(fitness, 0)
+	}),
+
+	// staying alive with a full stomach - up to 100 for living the whole life, up to 50 for calories
+	"survival": FitnessFuncOf(func(s EpisodeStats) float64 {
+		return 100*lifeFraction(s) + 50*math.Min(s.MeanCalories/1000, 1)
+	}),
+
+	// eating and growing, fighting only when it pays off
+	"forager": FitnessFuncOf(func(s EpisodeStats) float64 {
+		fitness := float64(s.TotalFoodEaten)*10 + float64(s.VitaminsTaken)*15 + float64(s.Evolutions)*100 + 20*lifeFraction(s)
+		return math.Max(fitness-s.DamageTaken/5, 0)
+	}),
+
+	// killing enemies and winning fights
+	"hunter": FitnessFuncOf(func(s EpisodeStats) float64 {
+		fitness := float64(s.TotalEnemiesKilled)*60 + s.DamageDealt - s.DamageTaken/2 + 10*lifeFraction(s)
+		return math.Max(fitness, 0)
+	}),
+
+	// moving around the map instead of waiting for food to come
+	"explorer": FitnessFuncOf(func(s EpisodeStats) float64 {
+		return s.Distance/100 + float64(s.TotalFoodEaten)*5 + 20*lifeFraction(s)
+	}),
+}
+
+func LookupFitness(name string) (FitnessFunc, error) {
+	// returns the built-in fitness function with the given name ("" - DefaultFitness)
+	if name == "" {
+		name = DefaultFitness
+	}
+	if f, ok := FitnessFuncs[name]; ok {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unknown fitness function %q (known: %s)", name, strings.Join(FitnessNames(), ", "))
+}
+
+func FitnessNames() []string {
+	// names of the built-in fitness functions, sorted
+	names := make([]string, 0, len(FitnessFuncs))
+	for name := range FitnessFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (genom *Genom) Evaluate(f FitnessFunc, stats EpisodeStats) float64 {
+	// scores the genome for the episode it played
+	genom.Stats = stats
+	genom.Fitness = f.Fitness(stats)
+	return genom.Fitness
+}
+
+func lifeFraction(s EpisodeStats) float64 {
+	// helper function
+	// part of its lifetime the genom lived through
+	if s.LifetimeFrames <= 0 {
+		return 0
+	}
+	return math.Min(float64(s.FramesLived)/float64(s.LifetimeFrames), 1)
+}
@@ -0,0 +1,57 @@
+package data
+
+import "testing"
+
+func TestLegacyFitness(t *testing.T) {
+	legacy := FitnessFuncs["legacy"]
+	tests := []struct {
+		name  string
+		stats EpisodeStats
+		want  float64
+	}{
+		{"food and kills", EpisodeStats{Score: 150, FoodEaten: 2, EnemiesKilled: 1, TimeSurvived: 900, HP: 7.5}, 20 + 60 + 5 + 10},
+		{"capped hp and time", EpisodeStats{Score: 25, FoodEaten: 1, TimeSurvived: 3600, HP: 30}, 10 + 10 + 20},
+		{"idle whole life", EpisodeStats{TimeSurvived: 1800, HP: 15}, 0},
+	}
+	for _, tt := range tests {
+		if got := legacy.Fitness(tt.stats); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFitnessFuncs(t *testing.T) {
+	stats := EpisodeStats{
+		LifetimeFrames: 1800, FramesLived: 900,
+		TotalFoodEaten: 3, TotalEnemiesKilled: 1, VitaminsTaken: 1, Evolutions: 1,
+		DamageDealt: 20, DamageTaken: 10, Distance: 2000, MeanCalories: 500,
+		FoodEaten: 1, TimeSurvived: 900, HP: 10,
+	}
+	for _, name := range FitnessNames() {
+		f, err := LookupFitness(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := f.Fitness(EpisodeStats{}); got < 0 {
+			t.Errorf("%s: empty episode scored %v", name, got)
+		}
+		if got := f.Fitness(stats); got <= 0 {
+			t.Errorf("%s: active episode scored %v", name, got)
+		}
+	}
+	if f, err := LookupFitness(""); err != nil || f == nil {
+		t.Fatalf("default fitness: %v", err)
+	}
+	if _, err := LookupFitness("nope"); err == nil {
+		t.Fatal("unknown fitness function was accepted")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	genom := &Genom{}
+	stats := EpisodeStats{FoodEaten: 4}
+	got := genom.Evaluate(FitnessFuncOf(func(s EpisodeStats) float64 { return float64(s.FoodEaten) }), stats)
+	if got != 4 || genom.Fitness != 4 || genom.Stats != stats {
+		t.Fatalf("Evaluate set Fitness %v, Stats %+v", genom.Fitness, genom.Stats)
+	}
+}
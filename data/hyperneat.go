@@ -0,0 +1,132 @@
+package data
+
+import (
+	"math"
+)
+
+// – – – – – – – – – – – – – – – – – – HYPERNEAT – – – – – – – – – – – – – – – – – – – – – – –
+// every input of a plain NEAT genome is a separate gene, a high resolution sensor grid
+// would need hundreds of them and evolution would have to wire each one by hand
+// in HyperNEAT the evolved genome is a CPPN: it is asked for the weight between every pair
+// of points of a fixed substrate (a grid of sensors and a grid of outputs) and so paints
+// the whole weight pattern at once - things on the left of a sensor grid can drive
+// outputs on the left with a single gene
+
+// a CPPN gets the coordinates of both ends of a substrate connection (x1, y1, z1 -> x2, y2)
+// and returns its weight and the bias of the target
+const (
+	CPPNInputs  = 5
+	CPPNOutputs = 2
+)
+
+// SubstratePoint is the place of a substrate node, coordinates are in [-1, 1]
+// Z separates input sheets which see different things (e.g. food and enemies)
+type SubstratePoint struct {
+	X, Y, Z float64
+}
+
+type Substrate struct {
+	Inputs          []SubstratePoint
+	Outputs         []SubstratePoint
+	WeightThreshold float64 // CPPN outputs closer to 0 don't make a connection
+	MaxWeight       float64 // CPPN outputs of 1 and -1 become this weight
+}
+
+// substrate defaults
+const (
+	DefaultWeightThreshold = 0.2
+	DefaultSubstrateWeight = 3.0
+)
+
+func GridPoints(size int, z float64) []SubstratePoint {
+	// size x size points evenly covering [-1, 1] x [-1, 1], row by row from the top left
+	// (a single point sits in the middle)
+	points := make([]SubstratePoint, 0, size*size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			points = append(points, SubstratePoint{X: gridCoord(col, size), Y: gridCoord(row, size), Z: z})
+		}
+	}
+	return points
+}
+
+func gridCoord(i, size int) float64 {
+	// helper function
+	if size <= 1 {
+		return 0
+	}
+	return -1 + 2*float64(i)/float64(size-1)
+}
+
+// SubstrateNetwork is a substrate with the weights painted by a CPPN,
+// a single layer network from the inputs straight to the outputs (tanh)
+// like Network it is not safe for concurrent use
+type SubstrateNetwork struct {
+	weights [][]float64 // by output, by input
+	bias    []float64
+	out     []float64
+}
+
+func (s *Substrate) Build(cppn *Genom) *SubstrateNetwork {
+	// queries the CPPN for every connection of the substrate
+	// the bias of an output is what the CPPN paints for a connection from the centre (0, 0, 0)
+	brain := cppn.Compile()
+	query := make([]float64, CPPNInputs)
+	net := &SubstrateNetwork{
+		weights: make([][]float64, len(s.Outputs)),
+		bias:    make([]float64, len(s.Outputs)),
+		out:     make([]float64, len(s.Outputs)),
+	}
+	for j, to := range s.Outputs {
+		net.weights[j] = make([]float64, len(s.Inputs))
+		for i, from := range s.Inputs {
+			query[0], query[1], query[2], query[3], query[4] = from.X, from.Y, from.Z, to.X, to.Y
+			brain.Reset()
+			net.weights[j][i] = s.expressed(brain.Activate(query)[0])
+		}
+		query[0], query[1], query[2], query[3], query[4] = 0, 0, 0, to.X, to.Y
+		brain.Reset()
+		if outputs := brain.Activate(query); len(outputs) > 1 {
+			net.bias[j] = s.expressed(outputs[1])
+		}
+	}
+	return net
+}
+
+func (s *Substrate) expressed(value float64) float64 {
+	// helper function
+	// CPPN output -> substrate weight, weak outputs are no connection at all
+	value = math.Max(-1, math.Min(1, value))
+	if math.Abs(value) <= s.WeightThreshold {
+		return 0
+	}
+	scaled := (math.Abs(value) - s.WeightThreshold) / (1 - s.WeightThreshold) * s.MaxWeight
+	return math.Copysign(scaled, value)
+}
+
+func (net *SubstrateNetwork) Activate(inputs []float64) []float64 {
+	// returns values of the output nodes, the slice is reused by the next call
+	for j, weights := range net.weights {
+		sum := net.bias[j]
+		for i, w := range weights {
+			if i < len(inputs) {
+				sum += inputs[i] * w
+			}
+		}
+		net.out[j] = math.Tanh(sum)
+	}
+	return net.out
+}
+
+func (net *SubstrateNetwork) Connections() int {
+	// number of connections the CPPN expressed
+	n := 0
+	for _, weights := range net.weights {
+		for _, w := range weights {
+			if w != 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package data
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestGridPoints(t *testing.T) {
+	points := GridPoints(3, 1)
+	if len(points) != 9 {
+		t.Fatalf("%d points, want 9", len(points))
+	}
+	if points[0] != (SubstratePoint{-1, -1, 1}) || points[5] != (SubstratePoint{1, 0, 1}) || points[8] != (SubstratePoint{1, 1, 1}) {
+		t.Fatalf("points %v", points)
+	}
+	if single := GridPoints(1, 0); single[0] != (SubstratePoint{}) {
+		t.Fatalf("single point %v, want the centre", single[0])
+	}
+}
+
+func TestSubstrateExpressed(t *testing.T) {
+	s := &Substrate{WeightThreshold: 0.2, MaxWeight: 3}
+	tests := []struct{ in, want float64 }{
+		{0.1, 0}, {-0.2, 0}, {0.6, 1.5}, {-1, -3}, {5, 3},
+	}
+	for _, tt := range tests {
+		if got := s.expressed(tt.in); math.Abs(got-tt.want) > 1e-12 {
+			t.Fatalf("expressed(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSubstrateBuild(t *testing.T) {
+	// a CPPN which only sees x1: weight = sigmoid(4 * x1), bias = sigmoid(0)
+	cppn := &Genom{
+		NumInputs:  CPPNInputs,
+		NumOutputs: CPPNOutputs,
+		IH:         &InnovationHistory{History: make(map[InnovationKey]int)},
+		Rand:       rand.New(rand.NewPCG(1, 2)),
+	}
+	cppn.CreateNetwork()
+	for i := range cppn.Connections {
+		cppn.Connections[i].Enabled = false
+	}
+	cppn.Repair()
+	cppn.addConnetion(cppn.Nodes[0], cppn.Nodes[CPPNInputs], 4, true)
+	if err := cppn.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Substrate{Inputs: GridPoints(3, 0), Outputs: GridPoints(2, 0), WeightThreshold: 0.2, MaxWeight: 3}
+	net := s.Build(cppn)
+	if len(net.weights) != 4 || len(net.weights[0]) != 9 {
+		t.Fatalf("substrate has %dx%d weights, want 4x9", len(net.weights), len(net.weights[0]))
+	}
+	for j := range net.weights {
+		// the middle column (x1 = 0) gives sigmoid(0) = 0, below the threshold
+		if net.weights[j][1] != 0 || net.weights[j][0] >= 0 || net.weights[j][2] <= 0 || net.bias[j] != 0 {
+			t.Fatalf("output %d: weights %v, bias %v", j, net.weights[j], net.bias[j])
+		}
+	}
+	if n := net.Connections(); n != 4*6 {
+		t.Fatalf("%d connections expressed, want 24", n)
+	}
+
+	inputs := make([]float64, 9)
+	inputs[2] = 1 // something top right
+	for _, out := range net.Activate(inputs) {
+		if out <= 0 {
+			t.Fatalf("outputs %v, want all positive", net.out)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package data
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func validGenom() *Genom {
+	genom := &Genom{
+		NumInputs:        3,
+		NumOutputs:       2,
+		ConnCreationRate: 1.0,
+		IH:               &InnovationHistory{History: make(map[InnovationKey]int)},
+		Rand:             rand.New(rand.NewPCG(1, 2)),
+	}
+	genom.CreateNetwork()
+	for i := 0; i < 3; i++ {
+		genom.mutateAddNode()
+		genom.mutateAddConnection()
+	}
+	return genom
+}
+
+func TestMutateKeepsFeedForward(t *testing.T) {
+	cfg := DefaultNEATConfig()
+	cfg.AddConnectionRate, cfg.AddNodeRate, cfg.ToggleConnectionRate, cfg.ActivationRate = 1, 0.5, 0.5, 0.1
+	for seed := uint64(0); seed < 20; seed++ {
+		genom := validGenom()
+		genom.Rand = rand.New(rand.NewPCG(seed, 0))
+		for i := 0; i < 300; i++ {
+			genom.Mutate(&cfg, cfg.WeightPerturbPower)
+			if _, err := genom.TopologicalOrder(); err != nil {
+				t.Fatalf("seed %d, mutation %d: %v", seed, i, err)
+			}
+		}
+	}
+}
+
+func TestCrossoverKeepsFeedForward(t *testing.T) {
+	// equally fit parents, each has one of the opposite connections between two hidden nodes enabled
+	parent1 := validGenom()
+	for i := 0; i < 2; i++ {
+		parent1.Nodes = append(parent1.Nodes, &Node{ID: parent1.TotalNodes, Type: Hidden})
+		parent1.TotalNodes++
+	}
+	n := len(parent1.Nodes)
+	parent1.addConnetion(parent1.Nodes[n-2], parent1.Nodes[n-1], 1, true)
+	parent1.addConnetion(parent1.Nodes[n-1], parent1.Nodes[n-2], 1, false)
+	parent2 := CloneGenom(parent1)
+	last := len(parent2.Connections) - 1
+	parent2.Connections[last-1].Enabled = false
+	parent2.Connections[last].Enabled = true
+
+	cfg := DefaultNEATConfig()
+	cfg.DisabledGeneRate = 0
+	for seed := uint64(0); seed < 20; seed++ {
+		child := crossover(parent1, parent2, rand.New(rand.NewPCG(seed, 0)), &cfg)
+		if _, err := child.TopologicalOrder(); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+	}
+}
+
+func TestRandomNodes(t *testing.T) {
+	genom := validGenom()
+	for i := 0; i < 10; i++ {
+		genom.mutateAddNode()
+	}
+	for i := 0; i < 2000; i++ {
+		n1, n2 := genom.randomNodes()
+		switch {
+		case n2.Type == Input || n2.Type == Bias:
+			t.Fatalf("%v node %d is a target", n2.Type, n2.ID)
+		case n1.Type == Output:
+			t.Fatalf("output node %d is a source", n1.ID)
+		case n1 == n2:
+			t.Fatalf("self-loop on node %d", n1.ID)
+		case genom.reaches(n2, n1):
+			t.Fatalf("connection %d -> %d closes a cycle", n1.ID, n2.ID)
+		}
+	}
+
+	genom.Recurrent = true
+	loops := false
+	for i := 0; i < 2000; i++ {
+		n1, n2 := genom.randomNodes()
+		if n2.Type == Input || n2.Type == Bias {
+			t.Fatalf("%v node %d is a target", n2.Type, n2.ID)
+		}
+		loops = loops || n1 == n2
+	}
+	if !loops {
+		t.Fatal("a recurrent genome never got a self-loop")
+	}
+}
+
+func TestMutateActivationBoundedOutputs(t *testing.T) {
+	genom := validGenom()
+	hidden := map[Activation]bool{}
+	for i := 0; i < 500; i++ {
+		genom.mutateActivation()
+		for _, node := range genom.Nodes {
+			switch node.Type {
+			case Output:
+				if a := node.activation(); a != Tanh && a != Sigmoid {
+					t.Fatalf("output node %d got activation %v", node.ID, a)
+				}
+			case Hidden:
+				hidden[node.activation()] = true
+			}
+		}
+	}
+	if len(hidden) <= len(OutputActivations) {
+		t.Fatalf("hidden nodes only got %v", hidden)
+	}
+}
+
+// splitGenom builds a genome with 2 inputs and an output,
+// connected only by input -> output, sharing the innovation history ih
+func splitGenom(ih *InnovationHistory, input int) *Genom {
+	genom := &Genom{NumInputs: 2, NumOutputs: 1, IH: ih, Rand: rand.New(rand.NewPCG(1, 2))}
+	genom.Nodes = []*Node{{ID: 0, Type: Input}, {ID: 1, Type: Input}, {ID: 2, Type: Output}}
+	genom.TotalNodes = len(genom.Nodes)
+	ih.reserveNodes(genom.TotalNodes)
+	genom.addConnetion(genom.Nodes[input], genom.Nodes[2], 0.5, true)
+	return genom
+}
+
+func TestSplitNodeSharedInGeneration(t *testing.T) {
+	ih := &InnovationHistory{History: make(map[InnovationKey]int)}
+	first, second, other := splitGenom(ih, 0), splitGenom(ih, 0), splitGenom(ih, 1)
+	before := ih.NodeCounter
+
+	// both genomes split the same connection
+	first.mutateAddNode()
+	second.mutateAddNode()
+	if len(first.Nodes) != 4 || len(second.Nodes) != 4 {
+		t.Fatal("mutateAddNode didn't add a node")
+	}
+	node := first.Nodes[3].ID
+	if second.Nodes[3].ID != node {
+		t.Fatalf("the same split gave nodes %d and %d", node, second.Nodes[3].ID)
+	}
+	if node < before || ih.NodeCounter != before+1 {
+		t.Fatalf("node %d, NodeCounter %d, want %d and %d", node, ih.NodeCounter, before, before+1)
+	}
+	for i := 1; i < 3; i++ {
+		if first.Connections[i].Innovation != second.Connections[i].Innovation {
+			t.Fatalf("connection %d: innovations %d and %d", i, first.Connections[i].Innovation, second.Connections[i].Innovation)
+		}
+	}
+	if got := ih.SplitNode(first.Connections[0].Innovation, 0); got != node {
+		t.Fatalf("SplitNode = %d, want %d", got, node)
+	}
+
+	// a different connection gets a new node and new innovations
+	other.mutateAddNode()
+	if id := other.Nodes[3].ID; id == node || ih.NodeCounter != before+2 {
+		t.Fatalf("a different split gave node %d (NodeCounter %d), the first split gave %d", id, ih.NodeCounter, node)
+	}
+	seen := map[int]bool{}
+	for _, conn := range first.Connections {
+		seen[conn.Innovation] = true
+	}
+	for _, conn := range other.Connections {
+		if seen[conn.Innovation] {
+			t.Fatalf("innovation %d is reused by a different split", conn.Innovation)
+		}
+	}
+}
+
+func TestResplitSharedInGeneration(t *testing.T) {
+	// two genomes split the same disabled connection a second time
+	ih := &InnovationHistory{History: make(map[InnovationKey]int)}
+	first, second := splitGenom(ih, 0), splitGenom(ih, 0)
+	for _, genom := range []*Genom{first, second} {
+		genom.mutateAddNode()
+		// only the disabled input -> output connection may be split again
+		genom.Connections = genom.Connections[:1]
+		genom.mutateAddNode()
+		if len(genom.Nodes) != 5 {
+			t.Fatalf("%d nodes after splitting twice, want 5", len(genom.Nodes))
+		}
+	}
+	if first.Nodes[3].ID == first.Nodes[4].ID {
+		t.Fatalf("the re-split reused node %d", first.Nodes[3].ID)
+	}
+	if first.Nodes[4].ID != second.Nodes[4].ID {
+		t.Fatalf("the same re-split gave nodes %d and %d", first.Nodes[4].ID, second.Nodes[4].ID)
+	}
+	if got := ih.SplitNode(first.Connections[0].Innovation, 1); got != first.Nodes[4].ID {
+		t.Fatalf("SplitNode(_, 1) = %d, want %d", got, first.Nodes[4].ID)
+	}
+}
+
+func TestMutateWeightBounds(t *testing.T) {
+	genom := validGenom()
+	cfg := DefaultNEATConfig()
+	cfg.WeightPerturbation = GaussianPerturbation
+	cfg.WeightPerturbPower = 5
+	cfg.MinWeight, cfg.MaxWeight = -2, 3
+	for i := 0; i < 100; i++ {
+		genom.mutateWeight(&cfg, cfg.WeightPerturbPower)
+		for _, conn := range genom.Connections {
+			if conn.Weight < cfg.MinWeight || conn.Weight > cfg.MaxWeight {
+				t.Fatalf("weight %v outside [%v, %v]", conn.Weight, cfg.MinWeight, cfg.MaxWeight)
+			}
+		}
+	}
+}
+
+func TestDefaultWeightsUnbounded(t *testing.T) {
+	cfg := DefaultNEATConfig()
+	if got := cfg.clampWeight(100); got != 100 {
+		t.Fatalf("clampWeight(100) = %v with the default config, want 100", got)
+	}
+}
+
+func TestMutateWeightRate(t *testing.T) {
+	genom := validGenom()
+	cfg := DefaultNEATConfig()
+	cfg.WeightMutationRate = 0
+	before := CloneGenom(genom)
+	genom.mutateWeight(&cfg, cfg.WeightPerturbPower)
+	for i, conn := range genom.Connections {
+		if conn.Weight != before.Connections[i].Weight {
+			t.Fatalf("connection %d mutated with weight_mutation_rate 0", conn.Innovation)
+		}
+	}
+}
+
+func TestSelfAdaptiveSigma(t *testing.T) {
+	genom := validGenom()
+	cfg := DefaultNEATConfig()
+	cfg.SelfAdaptiveSigma = true
+	genom.mutateWeight(&cfg, cfg.WeightPerturbPower)
+	if genom.WeightSigma <= 0 || genom.WeightSigma == cfg.WeightPerturbPower {
+		t.Fatalf("WeightSigma = %v, want it initialised and adapted", genom.WeightSigma)
+	}
+	if clone := CloneGenom(genom); clone.WeightSigma != genom.WeightSigma {
+		t.Fatalf("clone has WeightSigma %v, want %v", clone.WeightSigma, genom.WeightSigma)
+	}
+}
+
+func TestRandomWeightSigned(t *testing.T) {
+	genom := &Genom{Rand: rand.New(rand.NewPCG(1, 2))}
+	negative := false
+	for i := 0; i < 100; i++ {
+		w := genom.randomWeight()
+		if w < -1 || w >= 1 {
+			t.Fatalf("weight %v outside [-1, 1)", w)
+		}
+		negative = negative || w < 0
+	}
+	if !negative {
+		t.Fatal("no negative weights drawn")
+	}
+}
+
+func TestWeightSigmaDecay(t *testing.T) {
+	cfg := DefaultNEATConfig()
+	cfg.WeightSigmaDecay = 0.5
+	if got := cfg.weightSigma(2); got != cfg.WeightPerturbPower/4 {
+		t.Fatalf("weightSigma(2) = %v, want %v", got, cfg.WeightPerturbPower/4)
+	}
+}
+
+func TestMutateDelete(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	for i := 0; i < 100; i++ {
+		genom := validGenom()
+		genom.Rand = rng
+		for j := 0; j < 10; j++ {
+			genom.mutateAddNode()
+			genom.mutateAddConnection()
+			genom.mutateDeleteConnection()
+			genom.mutateDeleteNode()
+			if err := genom.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if len(genom.Connections) == 0 {
+				t.Fatal("genom lost all its connections")
+			}
+		}
+		genom.Forward(make([]float64, genom.NumInputs))
+	}
+}
+
+func TestMutateDeleteNode(t *testing.T) {
+	genom := validGenom()
+	hidden := 0
+	for _, node := range genom.Nodes {
+		if node.Type == Hidden {
+			hidden++
+		}
+	}
+	genom.mutateDeleteNode()
+	for _, node := range genom.Nodes {
+		if node.Type == Hidden {
+			hidden--
+		}
+	}
+	if hidden != 1 {
+		t.Fatalf("%d hidden nodes removed, want 1", hidden)
+	}
+	remaining := map[int]bool{}
+	for _, node := range genom.Nodes {
+		remaining[node.ID] = true
+	}
+	for _, conn := range genom.Connections {
+		if !remaining[conn.InNode.ID] || !remaining[conn.OutNode.ID] {
+			t.Fatalf("connection %d still points at the removed node", conn.Innovation)
+		}
+	}
+}
+
+func TestBiasNode(t *testing.T) {
+	genom := &Genom{
+		NumInputs:  3,
+		NumOutputs: 1,
+		IH:         &InnovationHistory{History: make(map[InnovationKey]int)},
+		Rand:       rand.New(rand.NewPCG(1, 2)),
+	}
+	genom.CreateNetwork()
+	genom.Connections = nil
+	for _, node := range genom.Nodes {
+		node.IncomingConns = nil
+	}
+	bias, output := genom.Nodes[4], genom.Nodes[3]
+	if bias.Type != Bias || output.Type != Output {
+		t.Fatalf("got node types %v, %v, want Bias after Output", bias.Type, output.Type)
+	}
+	genom.addConnetion(bias, output, 2, true)
+
+	outputs, _ := genom.Forward(make([]float64, genom.NumInputs))
+	if want := sigmoid(2); outputs[0] != want {
+		t.Fatalf("output with all inputs 0 = %v, want %v", outputs[0], want)
+	}
+}
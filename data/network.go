@@ -0,0 +1,167 @@
+package data
+
+// – – – – – – – – – – – – – – – – COMPILED NETWORK – – – – – – – – – – – – – – – – – – – – –
+// Genom keeps nodes and connections in a form which is easy to mutate and cross over,
+// but slow to evaluate. Network is the same genom flattened into slices, so
+// evaluating it every frame doesn't allocate anything.
+
+// Network is a genom compiled for evaluation. It is not safe for concurrent use,
+// every goroutine playing a genom needs its own Network (see Genom.Compile).
+type Network struct {
+	inputs    []int          // slots of input nodes, in genome order
+	outputs   []int          // slots of output nodes, in genome order
+	bias      []int          // slots of bias nodes, always 1.0
+	nodes     []compiledNode // non-input nodes in evaluation order
+	links     []link         // incoming links of all nodes, nodes[i] uses links[first:last]
+	values    []float64      // activation of every node, indexed by slot
+	out       []float64      // returned by Activate, reused between calls
+	recurrent bool           // keeps values between Activate calls
+
+	explain []ConnectionInfo // enabled connections, Effect is filled in by Explain
+	from    []int            // slot of the source node of explain[i]
+	info    []NodeInfo       // hidden and output nodes in genome order, Value is filled in by Explain
+	infoAt  []int            // slot of info[i]
+}
+
+type compiledNode struct {
+	slot        int
+	act         Activation
+	first, last int
+}
+
+type link struct {
+	from   int
+	weight float64
+}
+
+func (genom *Genom) Compile() *Network {
+	// returns a new network evaluating the genom
+	// the network doesn't follow later changes of the genom, compile it again after mutating
+	net := &Network{recurrent: genom.Recurrent}
+
+	slots := make(map[int]int)
+	for _, node := range genom.Nodes {
+		if _, exists := slots[node.ID]; exists {
+			continue
+		}
+		slot := len(slots)
+		slots[node.ID] = slot
+		switch node.Type {
+		case Input:
+			net.inputs = append(net.inputs, slot)
+			continue
+		case Bias:
+			net.bias = append(net.bias, slot)
+			continue
+		case Output:
+			net.outputs = append(net.outputs, slot)
+		}
+		net.info = append(net.info, NodeInfo{ID: node.ID, Type: node.Type, Activation: node.activation()})
+		net.infoAt = append(net.infoAt, slot)
+	}
+	net.values = make([]float64, len(slots))
+	net.out = make([]float64, len(net.outputs))
+
+	// a non-recurrent network is evaluated in one pass, connections closing a cycle
+	// (mutations never add them, but a hand-edited genome may have them) are left out,
+	// otherwise nodes on the cycle would come after the outputs and read 0
+	var skip map[int]bool
+	if !genom.Recurrent {
+		skip = genom.cycleEdges()
+	}
+
+	// grouping enabled connections by the node they enter, in genome order
+	// (the same order Forward used to sum them in)
+	incoming := make(map[int][]link)
+	for _, conn := range genom.Connections {
+		if !conn.Enabled || skip[conn.Innovation] {
+			continue
+		}
+		from, okIn := slots[conn.InNode.ID]
+		_, okOut := slots[conn.OutNode.ID]
+		if !okIn || !okOut {
+			continue
+		}
+		incoming[conn.OutNode.ID] = append(incoming[conn.OutNode.ID], link{from: from, weight: conn.Weight})
+		net.explain = append(net.explain, ConnectionInfo{From: conn.InNode.ID, To: conn.OutNode.ID, Weight: conn.Weight})
+		net.from = append(net.from, from)
+	}
+
+	// without the skipped connections a non-recurrent genome has no cycle,
+	// in a recurrent one nodes on a cycle come last and read values of the previous Activate
+	order, _ := genom.topologicalOrder(skip)
+	for _, node := range order {
+		if node.Type == Input || node.Type == Bias {
+			continue
+		}
+		first := len(net.links)
+		net.links = append(net.links, incoming[node.ID]...)
+		net.nodes = append(net.nodes, compiledNode{
+			slot:  slots[node.ID],
+			act:   node.activation(),
+			first: first,
+			last:  len(net.links),
+		})
+	}
+	return net
+}
+
+func (net *Network) Activate(inputs []float64) []float64 {
+	// evaluates the network and returns values of output nodes
+	// the returned slice is reused by the next call - copy it to keep it
+	if !net.recurrent {
+		for i := range net.values {
+			net.values[i] = 0
+		}
+	}
+	for i, slot := range net.inputs {
+		if i < len(inputs) {
+			net.values[slot] = inputs[i]
+		} else {
+			net.values[slot] = 0
+		}
+	}
+	for _, slot := range net.bias {
+		net.values[slot] = 1
+	}
+	for _, node := range net.nodes {
+		sum := 0.0
+		for _, l := range net.links[node.first:node.last] {
+			sum += net.values[l.from] * l.weight
+		}
+		net.values[node.slot] = node.act.Apply(sum)
+	}
+	for i, slot := range net.outputs {
+		net.out[i] = net.values[slot]
+	}
+	return net.out
+}
+
+func (net *Network) Explain(inputs []float64) AIDecision {
+	// describes the last Activate call - what went in, what came out
+	// and how much every enabled connection contributed
+	// unlike Activate it allocates, so call it only when the explanation is shown
+	connections := make([]ConnectionInfo, len(net.explain))
+	for i, info := range net.explain {
+		info.Effect = net.values[net.from[i]] * info.Weight
+		connections[i] = info
+	}
+	nodes := make([]NodeInfo, len(net.info))
+	for i, info := range net.info {
+		info.Value = net.values[net.infoAt[i]]
+		nodes[i] = info
+	}
+	return AIDecision{
+		Inputs:      inputs,
+		Outputs:     append([]float64{}, net.out...),
+		Connections: connections,
+		Nodes:       nodes,
+	}
+}
+
+func (net *Network) Reset() {
+	// forgets activations remembered by a recurrent network
+	for i := range net.values {
+		net.values[i] = 0
+	}
+}
@@ -0,0 +1,194 @@
+package data
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// chainGenom builds input -> hidden -> ... -> hidden -> output, every weight 2,
+// so the expected output is easy to compute
+func chainGenom(hidden int) *Genom {
+	genom := &Genom{
+		NumInputs:  1,
+		NumOutputs: 1,
+		IH:         &InnovationHistory{History: make(map[InnovationKey]int)},
+		Rand:       rand.New(rand.NewPCG(1, 2)),
+	}
+	genom.Nodes = []*Node{{ID: 0, Type: Input}, {ID: 1, Type: Output}}
+	prev := genom.Nodes[0]
+	for i := 0; i < hidden; i++ {
+		node := &Node{ID: 2 + i, Type: Hidden}
+		genom.Nodes = append(genom.Nodes, node)
+		genom.addConnetion(prev, node, 2, true)
+		prev = node
+	}
+	genom.addConnetion(prev, genom.Nodes[1], 2, true)
+	genom.TotalNodes = len(genom.Nodes)
+	return genom
+}
+
+func chainOutput(hidden int, input float64) float64 {
+	value := input
+	for i := 0; i < hidden; i++ {
+		value = relu(2 * value)
+	}
+	return sigmoid(2 * value)
+}
+
+func TestHiddenChainReachesOutput(t *testing.T) {
+	for _, hidden := range []int{1, 3, 6} {
+		genom := chainGenom(hidden)
+		outputs, _ := genom.Forward([]float64{0.01})
+		if want := chainOutput(hidden, 0.01); math.Abs(outputs[0]-want) > 1e-12 {
+			t.Fatalf("%d hidden nodes: output %v, want %v", hidden, outputs[0], want)
+		}
+	}
+}
+
+func TestCycleLeftOutOfFeedForward(t *testing.T) {
+	// a self-loop on the hidden node mustn't push it behind the output
+	genom := chainGenom(2)
+	genom.addConnetion(genom.Nodes[3], genom.Nodes[3], 0.01, true)
+	genom.addConnetion(genom.Nodes[3], genom.Nodes[2], 0.01, true)
+	if _, err := genom.TopologicalOrder(); err != ErrCycle {
+		t.Fatalf("TopologicalOrder() error = %v, want ErrCycle", err)
+	}
+	outputs, _ := genom.Forward([]float64{0.01})
+	if want := chainOutput(2, 0.01); math.Abs(outputs[0]-want) > 1e-12 {
+		t.Fatalf("output %v, want %v (the cycle left out)", outputs[0], want)
+	}
+}
+
+func TestRecurrentState(t *testing.T) {
+	// input -> hidden -> output with a self-loop on the hidden node
+	genom := chainGenom(1)
+	genom.Recurrent = true
+	genom.addConnetion(genom.Nodes[2], genom.Nodes[2], 1, true)
+	net := genom.Compile()
+
+	// the hidden node is on a cycle, so it comes after the output,
+	// which reads the hidden value of the previous call
+	first := net.Activate([]float64{0.5})[0]
+	if first != 0 {
+		t.Fatalf("first output %v, want 0", first)
+	}
+	for _, hidden := range []float64{1, 2} {
+		if got, want := net.Activate([]float64{0.5})[0], sigmoid(2*hidden); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("output %v, want %v", got, want)
+		}
+	}
+
+	net.Reset()
+	if again := net.Activate([]float64{0.5})[0]; again != first {
+		t.Fatalf("after Reset output %v, want %v", again, first)
+	}
+
+	// Forward keeps the state too, ResetState clears it
+	a, _ := genom.Forward([]float64{0.5})
+	a0 := a[0]
+	b, _ := genom.Forward([]float64{0.5})
+	if b[0] == a0 {
+		t.Fatal("Forward didn't keep the recurrent state")
+	}
+	genom.ResetState()
+	if c, _ := genom.Forward([]float64{0.5}); c[0] != a0 {
+		t.Fatalf("after ResetState output %v, want %v", c[0], a0)
+	}
+}
+
+func TestActivateMatchesForward(t *testing.T) {
+	genom := benchGenom()
+	net := genom.Compile()
+	for i := 0; i < 5; i++ {
+		inputs := benchInputs()
+		inputs[i] = -1
+		outputs, decision := genom.Forward(inputs)
+		activated := net.Activate(inputs)
+		explained := net.Explain(inputs)
+		for j := range outputs {
+			if activated[j] != outputs[j] || explained.Outputs[j] != outputs[j] || decision.Outputs[j] != outputs[j] {
+				t.Fatalf("output %d: Activate %v, Explain %v, Forward %v", j, activated[j], explained.Outputs[j], outputs[j])
+			}
+		}
+		if len(explained.Connections) != len(decision.Connections) {
+			t.Fatal("Explain and Forward describe different networks")
+		}
+	}
+	// a feed-forward network gives the same answer every time
+	first := append([]float64{}, net.Activate(benchInputs())...)
+	for j, v := range net.Activate(benchInputs()) {
+		if v != first[j] {
+			t.Fatalf("output %d changed between calls: %v, %v", j, first[j], v)
+		}
+	}
+}
+
+// benchGenom builds a genom shaped like the ones EVA evolves:
+// 15 inputs, 2 outputs and a few generations worth of structural mutations
+func benchGenom() *Genom {
+	genom := &Genom{
+		NumInputs:        15,
+		NumOutputs:       2,
+		ConnCreationRate: 1.0,
+		IH:               &InnovationHistory{History: make(map[InnovationKey]int)},
+		Rand:             rand.New(rand.NewPCG(1, 2)),
+	}
+	genom.CreateNetwork()
+	for i := 0; i < 10; i++ {
+		genom.mutateAddNode()
+	}
+	for i := 0; i < 20; i++ {
+		genom.mutateAddConnection()
+	}
+	return genom
+}
+
+func benchInputs() []float64 {
+	inputs := make([]float64, 15)
+	for i := range inputs {
+		inputs[i] = float64(i) / 15
+	}
+	return inputs
+}
+
+func BenchmarkForward(b *testing.B) {
+	genom := benchGenom()
+	inputs := benchInputs()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		genom.Forward(inputs)
+	}
+}
+
+func BenchmarkActivate(b *testing.B) {
+	net := benchGenom().Compile()
+	inputs := benchInputs()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.Activate(inputs)
+	}
+}
+
+func BenchmarkActivateRecurrent(b *testing.B) {
+	genom := benchGenom()
+	genom.Recurrent = true
+	net := genom.Compile()
+	inputs := benchInputs()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.Activate(inputs)
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	genom := benchGenom()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		genom.Compile()
+	}
+}
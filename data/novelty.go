@@ -0,0 +1,130 @@
+package data
+
+import (
+	"math"
+	"sort"
+)
+
+// – – – – – – – – – – – – – – – – – NOVELTY SEARCH – – – – – – – – – – – – – – – – – – – – –
+// fitness alone is deceptive - agents learn to sit still and wait for enemies to come
+// novelty search rewards behaving differently from what the run has already seen,
+// every episode is described by a behaviour vector (Genom.Behaviour, filled in by the world)
+// and a genome's novelty is its mean distance to the k nearest behaviours of
+// the current generation and of the archive of earlier novel ones
+
+type NoveltyArchive struct {
+	Behaviours [][]float64 `json:"behaviours"` // oldest first
+}
+
+func (pop *Population) EvaluateNovelty(genoms []*Genom) {
+	// sets Novelty of every genome of the evaluated generation and adds the most novel
+	// behaviours to the archive, does nothing unless Config.NoveltyWeight is set
+	cfg := &pop.Config
+	if cfg.NoveltyWeight <= 0 {
+		return
+	}
+	if pop.Novelty == nil {
+		pop.Novelty = &NoveltyArchive{}
+	}
+
+	others := make([][]float64, 0, len(genoms)+len(pop.Novelty.Behaviours))
+	for _, genom := range genoms {
+		others = append(others, genom.Behaviour)
+	}
+	others = append(others, pop.Novelty.Behaviours...)
+	for i, genom := range genoms {
+		genom.Novelty = novelty(genom.Behaviour, others, i, cfg.NoveltyK)
+	}
+
+	// most novel behaviours of the generation are remembered
+	ranked := append([]*Genom{}, genoms...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Novelty > ranked[j].Novelty
+	})
+	for i := 0; i < cfg.NoveltyArchiveAdd && i < len(ranked); i++ {
+		if ranked[i].Behaviour != nil {
+			pop.Novelty.Behaviours = append(pop.Novelty.Behaviours, ranked[i].Behaviour)
+		}
+	}
+	if over := len(pop.Novelty.Behaviours) - cfg.NoveltyArchiveSize; over > 0 {
+		pop.Novelty.Behaviours = append([][]float64{}, pop.Novelty.Behaviours[over:]...)
+	}
+}
+
+func novelty(behaviour []float64, others [][]float64, self, k int) float64 {
+	// helper function
+	// mean distance to the k nearest behaviours of others, skipping others[self]
+	distances := make([]float64, 0, len(others))
+	for i, other := range others {
+		if i == self || other == nil {
+			continue
+		}
+		distances = append(distances, behaviourDistance(behaviour, other))
+	}
+	if len(distances) == 0 {
+		return 0
+	}
+	sort.Float64s(distances)
+	if k > len(distances) {
+		k = len(distances)
+	}
+	sum := 0.0
+	for _, d := range distances[:k] {
+		sum += d
+	}
+	return sum / float64(k)
+}
+
+func behaviourDistance(b1, b2 []float64) float64 {
+	// helper function
+	// euclidean distance, missing values count as 0
+	sum := 0.0
+	for i := 0; i < max(len(b1), len(b2)); i++ {
+		var v1, v2 float64
+		if i < len(b1) {
+			v1 = b1[i]
+		}
+		if i < len(b2) {
+			v2 = b2[i]
+		}
+		sum += (v1 - v2) * (v1 - v2)
+	}
+	return math.Sqrt(sum)
+}
+
+func (pop *Population) scoreGenomes() {
+	// sets the selection score of every genome: its fitness, or with Config.NoveltyWeight
+	// a blend of fitness and novelty, both scaled by the best value of the generation
+	// (with Config.MultiObjective its Pareto rank and crowding, see scoreParetoRanks)
+	if pop.Config.MultiObjective {
+		genoms := []*Genom{}
+		for _, species := range pop.AllSpecies {
+			genoms = append(genoms, species.Genoms...)
+		}
+		pop.scoreParetoRanks(genoms)
+		return
+	}
+	w := pop.Config.NoveltyWeight
+	maxFitness, maxNovelty := 0.0, 0.0
+	for _, species := range pop.AllSpecies {
+		for _, genom := range species.Genoms {
+			maxFitness = math.Max(maxFitness, genom.Fitness)
+			maxNovelty = math.Max(maxNovelty, genom.Novelty)
+		}
+	}
+	for _, species := range pop.AllSpecies {
+		for _, genom := range species.Genoms {
+			if w <= 0 {
+				genom.score = genom.Fitness
+				continue
+			}
+			genom.score = 0
+			if maxFitness > 0 {
+				genom.score += (1 - w) * genom.Fitness / maxFitness
+			}
+			if maxNovelty > 0 {
+				genom.score += w * genom.Novelty / maxNovelty
+			}
+		}
+	}
+}
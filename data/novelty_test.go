@@ -0,0 +1,76 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func noveltyGenoms(behaviours ...[]float64) []*Genom {
+	genoms := []*Genom{}
+	for _, b := range behaviours {
+		genoms = append(genoms, &Genom{Behaviour: b})
+	}
+	return genoms
+}
+
+func TestEvaluateNovelty(t *testing.T) {
+	pop := &Population{Config: DefaultNEATConfig()}
+	pop.Config.NoveltyWeight = 1
+	pop.Config.NoveltyK = 2
+	pop.Config.NoveltyArchiveAdd = 1
+	pop.Config.NoveltyArchiveSize = 2
+
+	genoms := noveltyGenoms([]float64{0, 0}, []float64{1, 0}, []float64{0, 1}, []float64{10, 0})
+	pop.EvaluateNovelty(genoms)
+	want := []float64{1, (1 + math.Sqrt2) / 2, (1 + math.Sqrt2) / 2, (9 + 10) / 2.0}
+	for i, genom := range genoms {
+		if math.Abs(genom.Novelty-want[i]) > 1e-12 {
+			t.Fatalf("genom %d: novelty %v, want %v", i, genom.Novelty, want[i])
+		}
+	}
+	if len(pop.Novelty.Behaviours) != 1 || pop.Novelty.Behaviours[0][0] != 10 {
+		t.Fatalf("archive %v, want the most novel behaviour", pop.Novelty.Behaviours)
+	}
+
+	// the archived behaviour counts in the next generation, the oldest are dropped above the size
+	genoms = noveltyGenoms([]float64{10, 0}, []float64{20, 0})
+	pop.EvaluateNovelty(genoms)
+	if genoms[0].Novelty != 5 {
+		t.Fatalf("novelty %v, want 5 (the same behaviour is in the archive)", genoms[0].Novelty)
+	}
+	pop.EvaluateNovelty(noveltyGenoms([]float64{30, 0}))
+	if len(pop.Novelty.Behaviours) != 2 || pop.Novelty.Behaviours[0][0] != 20 || pop.Novelty.Behaviours[1][0] != 30 {
+		t.Fatalf("archive %v, want [[20 0] [30 0]]", pop.Novelty.Behaviours)
+	}
+}
+
+func TestEvaluateNoveltyOff(t *testing.T) {
+	pop := &Population{Config: DefaultNEATConfig()}
+	genoms := noveltyGenoms([]float64{0}, []float64{1})
+	pop.EvaluateNovelty(genoms)
+	if pop.Novelty != nil || genoms[0].Novelty != 0 {
+		t.Fatal("novelty was evaluated with novelty_weight 0")
+	}
+}
+
+func TestScoreGenomes(t *testing.T) {
+	genoms := []*Genom{{Fitness: 10, Novelty: 1}, {Fitness: 5, Novelty: 4}}
+	pop := &Population{Config: DefaultNEATConfig(), AllSpecies: []*Species{{Genoms: genoms}}}
+
+	pop.scoreGenomes()
+	if genoms[0].score != 10 || genoms[1].score != 5 {
+		t.Fatalf("scores %v, %v, want the fitness", genoms[0].score, genoms[1].score)
+	}
+
+	pop.Config.NoveltyWeight = 1
+	pop.scoreGenomes()
+	if genoms[0].score != 0.25 || genoms[1].score != 1 {
+		t.Fatalf("scores %v, %v, want the scaled novelty", genoms[0].score, genoms[1].score)
+	}
+
+	pop.Config.NoveltyWeight = 0.5
+	pop.scoreGenomes()
+	if genoms[0].score != 0.625 || genoms[1].score != 0.75 {
+		t.Fatalf("scores %v, %v, want the blend", genoms[0].score, genoms[1].score)
+	}
+}
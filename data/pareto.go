@@ -0,0 +1,189 @@
+package data
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// – – – – – – – – – – – – – – – MULTI-OBJECTIVE SELECTION – – – – – – – – – – – – – – – – – –
+// one fitness number hides the trade-off between aggressive and tank play,
+// with NEATConfig.MultiObjective genomes are compared by a vector of objectives instead (NSGA-II):
+// a genome is better when it is on a better Pareto front, and within a front when it is
+// in a less crowded part of it, so the whole range of play styles keeps breeding
+
+// ObjectiveNames are the names of the values EpisodeStats.Objectives returns, in order
+var ObjectiveNames = []string{"food", "kills", "survival", "hp"}
+
+// Objectives are the values multi-objective selection maximizes: food eaten, enemies killed,
+// frames survived and hp kept at the end of the episode
+func (s EpisodeStats) Objectives() []float64 {
+	return []float64{float64(s.TotalFoodEaten), float64(s.TotalEnemiesKilled), float64(s.FramesLived), s.HP}
+}
+
+func (pop *Population) objectives(genom *Genom) []float64 {
+	// helper function
+	// with novelty search novelty is one more objective
+	objectives := genom.Stats.Objectives()
+	if pop.Config.NoveltyWeight > 0 {
+		objectives = append(objectives, genom.Novelty)
+	}
+	return objectives
+}
+
+func dominates(o1, o2 []float64) bool {
+	// helper function
+	// o1 is at least as good as o2 in every objective and better in one
+	better := false
+	for i := range o1 {
+		if o1[i] < o2[i] {
+			return false
+		}
+		if o1[i] > o2[i] {
+			better = true
+		}
+	}
+	return better
+}
+
+func paretoFronts(objectives [][]float64) [][]int {
+	// helper function
+	// fast non-dominated sort, returns indices of objectives front by front, the best front first
+	dominatedBy := make([]int, len(objectives)) // how many others dominate i
+	dominating := make([][]int, len(objectives))
+	front := []int{}
+	for i := range objectives {
+		for j := range objectives {
+			if dominates(objectives[i], objectives[j]) {
+				dominating[i] = append(dominating[i], j)
+			} else if dominates(objectives[j], objectives[i]) {
+				dominatedBy[i]++
+			}
+		}
+		if dominatedBy[i] == 0 {
+			front = append(front, i)
+		}
+	}
+	fronts := [][]int{}
+	for len(front) > 0 {
+		fronts = append(fronts, front)
+		next := []int{}
+		for _, i := range front {
+			for _, j := range dominating[i] {
+				dominatedBy[j]--
+				if dominatedBy[j] == 0 {
+					next = append(next, j)
+				}
+			}
+		}
+		sort.Ints(next)
+		front = next
+	}
+	return fronts
+}
+
+func crowdingDistances(objectives [][]float64, front []int) []float64 {
+	// helper function
+	// crowding distance of every member of the front (in front order),
+	// the extremes of every objective are infinitely far from the rest
+	distances := make([]float64, len(front))
+	if len(front) == 0 {
+		return distances
+	}
+	order := make([]int, len(front))
+	for m := range objectives[front[0]] {
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return objectives[front[order[i]]][m] < objectives[front[order[j]]][m]
+		})
+		low, high := objectives[front[order[0]]][m], objectives[front[order[len(order)-1]]][m]
+		distances[order[0]] = math.Inf(1)
+		distances[order[len(order)-1]] = math.Inf(1)
+		if high == low {
+			continue
+		}
+		for i := 1; i < len(order)-1; i++ {
+			distances[order[i]] += (objectives[front[order[i+1]]][m] - objectives[front[order[i-1]]][m]) / (high - low)
+		}
+	}
+	return distances
+}
+
+func (pop *Population) scoreParetoRanks(genoms []*Genom) {
+	// helper function
+	// turns the crowded comparison of NSGA-II into a score: the best front gets the highest
+	// whole part, crowding adds up to 0.5 (reached by the extremes), so fronts never overlap
+	objectives := make([][]float64, len(genoms))
+	for i, genom := range genoms {
+		objectives[i] = pop.objectives(genom)
+	}
+	fronts := paretoFronts(objectives)
+	for rank, front := range fronts {
+		for i, d := range crowdingDistances(objectives, front) {
+			crowding := 1.0
+			if !math.IsInf(d, 1) {
+				crowding = d / (1 + d)
+			}
+			genoms[front[i]].score = float64(len(fronts)-rank) + 0.5*crowding
+		}
+	}
+}
+
+func (pop *Population) ParetoFront() []*Genom {
+	// genomes of the speciated generation no other genome dominates, in species order
+	genoms := []*Genom{}
+	for _, species := range pop.AllSpecies {
+		genoms = append(genoms, species.Genoms...)
+	}
+	objectives := make([][]float64, len(genoms))
+	for i, genom := range genoms {
+		objectives[i] = pop.objectives(genom)
+	}
+	front := []*Genom{}
+	if fronts := paretoFronts(objectives); len(fronts) > 0 {
+		for _, i := range fronts[0] {
+			front = append(front, genoms[i])
+		}
+	}
+	return front
+}
+
+func SaveParetoFront(filename string, pop *Population) error {
+	// writes the Pareto front of the speciated generation as CSV, one genom per line
+	// with its species, fitness and objectives
+	speciesOf := make(map[*Genom]int)
+	for _, species := range pop.AllSpecies {
+		for _, genom := range species.Genoms {
+			speciesOf[genom] = species.ID
+		}
+	}
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	header := append([]string{"species", "fitness"}, ObjectiveNames...)
+	if pop.Config.NoveltyWeight > 0 {
+		header = append(header, "novelty")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, genom := range pop.ParetoFront() {
+		record := []string{strconv.Itoa(speciesOf[genom]), strconv.FormatFloat(genom.Fitness, 'f', 4, 64)}
+		for _, objective := range pop.objectives(genom) {
+			record = append(record, strconv.FormatFloat(objective, 'f', 4, 64))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
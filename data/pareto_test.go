@@ -0,0 +1,85 @@
+package data
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParetoFronts(t *testing.T) {
+	objectives := [][]float64{
+		{1, 1}, // dominated by 1 and 2
+		{3, 1},
+		{1, 3},
+		{2, 2},
+		{0, 0}, // dominated by everything
+		{2, 2}, // equal to 3, neither dominates
+	}
+	fronts := paretoFronts(objectives)
+	want := [][]int{{1, 2, 3, 5}, {0}, {4}}
+	if len(fronts) != len(want) {
+		t.Fatalf("fronts %v, want %v", fronts, want)
+	}
+	for i := range want {
+		if len(fronts[i]) != len(want[i]) {
+			t.Fatalf("fronts %v, want %v", fronts, want)
+		}
+		for j := range want[i] {
+			if fronts[i][j] != want[i][j] {
+				t.Fatalf("fronts %v, want %v", fronts, want)
+			}
+		}
+	}
+}
+
+func TestCrowdingDistances(t *testing.T) {
+	objectives := [][]float64{{0, 4}, {1, 3}, {3, 1}, {4, 0}}
+	d := crowdingDistances(objectives, []int{0, 1, 2, 3})
+	if !math.IsInf(d[0], 1) || !math.IsInf(d[3], 1) {
+		t.Fatalf("extremes %v, want +Inf", d)
+	}
+	// (3-0)/4 for both objectives
+	if d[1] != 1.5 || d[2] != 1.5 {
+		t.Fatalf("distances %v, want 1.5 inside the front", d)
+	}
+}
+
+func TestScoreParetoRanks(t *testing.T) {
+	stats := []EpisodeStats{
+		{TotalFoodEaten: 10, FramesLived: 100},          // forager
+		{TotalEnemiesKilled: 3, FramesLived: 50, HP: 5}, // hunter
+		{TotalFoodEaten: 2, FramesLived: 200, HP: 15},   // tank
+		{TotalFoodEaten: 1, FramesLived: 40},            // dominated by the forager
+	}
+	genoms := []*Genom{}
+	for i, s := range stats {
+		genoms = append(genoms, &Genom{Fitness: float64(100 - i), Stats: s})
+	}
+	pop := &Population{Config: DefaultNEATConfig(), AllSpecies: []*Species{{ID: 4, Genoms: genoms}}}
+	pop.Config.MultiObjective = true
+	pop.scoreGenomes()
+	for i := 0; i < 3; i++ {
+		if genoms[i].score <= genoms[3].score {
+			t.Fatalf("genom %d on the front scored %v, the dominated one %v", i, genoms[i].score, genoms[3].score)
+		}
+	}
+
+	front := pop.ParetoFront()
+	if len(front) != 3 || front[0] != genoms[0] || front[2] != genoms[2] {
+		t.Fatalf("front has %d genomes, want the first 3", len(front))
+	}
+	filename := filepath.Join(t.TempDir(), "pareto.csv")
+	if err := SaveParetoFront(filename, pop); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 4 || lines[0] != "species,fitness,food,kills,survival,hp" || lines[1] != "4,100.0000,10.0000,0.0000,100.0000,0.0000" {
+		t.Fatalf("pareto front file:\n%s", b)
+	}
+}
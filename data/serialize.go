@@ -0,0 +1,365 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// – – – – – – – – – – – – – – – – SAVING AND LOADING – – – – – – – – – – – – – – – – – – – – –
+// JSON format which keeps everything needed to continue evolution
+// (innovation numbers, innovation counter, speciation constants...)
+// SavePopulationToFile is kept as a human readable, export-only view
+
+// PopulationFormatVersion is written to every saved population
+// bump it whenever the format changes in a way older code can't read
+const PopulationFormatVersion = 1
+
+type populationFile struct {
+	Version           int                   `json:"version"`
+	PopSize           int                   `json:"pop_size"`
+	CurrentGeneration int                   `json:"current_generation"`
+	Config            NEATConfig            `json:"config"`
+	NextSpeciesID     int                   `json:"next_species_id"`
+	Threshold         float64               `json:"threshold"`
+	Innovations       innovationHistoryFile `json:"innovations"`
+	Species           []speciesFile         `json:"species"`
+	Novelty           *NoveltyArchive       `json:"novelty,omitempty"` // only with novelty search
+}
+
+type innovationHistoryFile struct {
+	Counter     int              `json:"counter"`
+	History     []innovationFile `json:"history"`
+	NodeCounter int              `json:"node_counter"`
+	Splits      []splitFile      `json:"splits"`
+}
+
+type splitFile struct {
+	Connection int `json:"connection"` // innovation of the split connection
+	Nth        int `json:"nth"`        // how many times the genome split it before
+	Node       int `json:"node"`
+}
+
+type innovationFile struct {
+	InNode     int `json:"in"`
+	OutNode    int `json:"out"`
+	Innovation int `json:"innovation"`
+}
+
+type speciesFile struct {
+	ID             int         `json:"id"`
+	Representative *genomFile  `json:"representative,omitempty"`
+	Age            int         `json:"age"`
+	BestFitness    float64     `json:"best_fitness"`
+	Stagnation     int         `json:"stagnation"`
+	AverageFitness float64     `json:"average_fitness"`
+	BreedingRate   int         `json:"breeding_rate"`
+	Genoms         []genomFile `json:"genoms"`
+}
+
+type genomFile struct {
+	NumInputs        int              `json:"num_inputs"`
+	NumOutputs       int              `json:"num_outputs"`
+	TotalNodes       int              `json:"total_nodes"`
+	ConnCreationRate float64          `json:"conn_creation_rate"`
+	Fitness          float64          `json:"fitness"`
+	Recurrent        bool             `json:"recurrent,omitempty"`
+	WeightSigma      float64          `json:"weight_sigma,omitempty"`
+	Nodes            []nodeFile       `json:"nodes"`
+	Connections      []connectionFile `json:"connections"`
+}
+
+type nodeFile struct {
+	ID         int        `json:"id"`
+	Type       NodeType   `json:"type"`
+	Activation Activation `json:"activation,omitempty"` // missing means the default of the node type
+}
+
+type connectionFile struct {
+	InNode     int     `json:"in"`
+	OutNode    int     `json:"out"`
+	Weight     float64 `json:"weight"`
+	Innovation int     `json:"innovation"`
+	Enabled    bool    `json:"enabled"`
+}
+
+func (t NodeType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *NodeType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "Input":
+		*t = Input
+	case "Hidden":
+		*t = Hidden
+	case "Output":
+		*t = Output
+	case "Bias":
+		*t = Bias
+	default:
+		return fmt.Errorf("unknown node type %q", text)
+	}
+	return nil
+}
+
+func SavePopulationJSON(filename string, pop *Population) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := EncodePopulation(file, pop); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func LoadPopulationJSON(filename string) (*Population, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return DecodePopulation(file)
+}
+
+func EncodePopulation(w io.Writer, pop *Population) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(populationToFile(pop))
+}
+
+func DecodePopulation(r io.Reader) (*Population, error) {
+	var pf populationFile
+	if err := json.NewDecoder(r).Decode(&pf); err != nil {
+		return nil, err
+	}
+	return populationFromFile(&pf)
+}
+
+// MarshalJSON and UnmarshalJSON let a population be embedded in other files
+// (e.g. training checkpoints) in the same format SavePopulationJSON writes
+func (pop *Population) MarshalJSON() ([]byte, error) {
+	return json.Marshal(populationToFile(pop))
+}
+
+func (pop *Population) UnmarshalJSON(b []byte) error {
+	var pf populationFile
+	if err := json.Unmarshal(b, &pf); err != nil {
+		return err
+	}
+	loaded, err := populationFromFile(&pf)
+	if err != nil {
+		return err
+	}
+	*pop = *loaded
+	return nil
+}
+
+// a single genom is saved without its innovation history,
+// whoever loads it has to set IH to the history it belongs to
+func (genom *Genom) MarshalJSON() ([]byte, error) {
+	return json.Marshal(genomToFile(genom))
+}
+
+func (genom *Genom) UnmarshalJSON(b []byte) error {
+	var gf genomFile
+	if err := json.Unmarshal(b, &gf); err != nil {
+		return err
+	}
+	loaded, err := genomFromFile(&gf, nil)
+	if err != nil {
+		return err
+	}
+	*genom = *loaded
+	return nil
+}
+
+func populationToFile(pop *Population) *populationFile {
+	pf := &populationFile{
+		Version:           PopulationFormatVersion,
+		PopSize:           pop.PopSize,
+		CurrentGeneration: pop.CurrentGeneration,
+		Config:            pop.Config,
+		NextSpeciesID:     pop.NextSpeciesID,
+		Threshold:         pop.Threshold,
+		Innovations:       innovationHistoryToFile(pop.innovationHistory()),
+		Species:           []speciesFile{},
+		Novelty:           pop.Novelty,
+	}
+	for _, species := range pop.AllSpecies {
+		sf := speciesFile{
+			ID:             species.ID,
+			Age:            species.Age,
+			BestFitness:    species.BestFitness,
+			Stagnation:     species.Stagnation,
+			AverageFitness: species.AverageFitness,
+			BreedingRate:   species.BreedingRate,
+			Genoms:         []genomFile{},
+		}
+		for _, genom := range species.Genoms {
+			sf.Genoms = append(sf.Genoms, genomToFile(genom))
+		}
+		if species.Representative != nil {
+			rep := genomToFile(species.Representative)
+			sf.Representative = &rep
+		}
+		pf.Species = append(pf.Species, sf)
+	}
+	return pf
+}
+
+func populationFromFile(pf *populationFile) (*Population, error) {
+	if pf.Version != PopulationFormatVersion {
+		return nil, fmt.Errorf("unsupported population format version %d (supported: %d)", pf.Version, PopulationFormatVersion)
+	}
+	if err := pf.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	ih := innovationHistoryFromFile(pf.Innovations)
+	pop := &Population{
+		AllSpecies:        []*Species{},
+		PopSize:           pf.PopSize,
+		CurrentGeneration: pf.CurrentGeneration,
+		Config:            pf.Config,
+		NextSpeciesID:     pf.NextSpeciesID,
+		Threshold:         pf.Threshold,
+		IH:                ih,
+		Novelty:           pf.Novelty,
+	}
+	for _, sf := range pf.Species {
+		species := &Species{
+			ID:             sf.ID,
+			Age:            sf.Age,
+			BestFitness:    sf.BestFitness,
+			Stagnation:     sf.Stagnation,
+			AverageFitness: sf.AverageFitness,
+			BreedingRate:   sf.BreedingRate,
+		}
+		for j, gf := range sf.Genoms {
+			genom, err := genomFromFile(&gf, ih)
+			if err != nil {
+				return nil, fmt.Errorf("species %d, genom %d: %w", sf.ID, j, err)
+			}
+			species.Genoms = append(species.Genoms, genom)
+		}
+		if sf.Representative != nil {
+			rep, err := genomFromFile(sf.Representative, ih)
+			if err != nil {
+				return nil, fmt.Errorf("species %d, representative: %w", sf.ID, err)
+			}
+			species.Representative = rep
+		}
+		pop.AllSpecies = append(pop.AllSpecies, species)
+	}
+	return pop, nil
+}
+
+func innovationHistoryToFile(ih *InnovationHistory) innovationHistoryFile {
+	ihf := innovationHistoryFile{History: []innovationFile{}}
+	if ih == nil {
+		return ihf
+	}
+	ihf.Counter = ih.Counter
+	for key, inno := range ih.History {
+		ihf.History = append(ihf.History, innovationFile{InNode: key.inNodeID, OutNode: key.outNodeID, Innovation: inno})
+	}
+	// map order is random, sorting keeps saved files comparable
+	sort.Slice(ihf.History, func(i, j int) bool {
+		return ihf.History[i].Innovation < ihf.History[j].Innovation
+	})
+	ihf.NodeCounter = ih.NodeCounter
+	ihf.Splits = []splitFile{}
+	for key, node := range ih.Splits {
+		ihf.Splits = append(ihf.Splits, splitFile{Connection: key.connection, Nth: key.nth, Node: node})
+	}
+	sort.Slice(ihf.Splits, func(i, j int) bool {
+		a, b := ihf.Splits[i], ihf.Splits[j]
+		return a.Connection < b.Connection || a.Connection == b.Connection && a.Nth < b.Nth
+	})
+	return ihf
+}
+
+func innovationHistoryFromFile(ihf innovationHistoryFile) *InnovationHistory {
+	ih := &InnovationHistory{
+		History:     make(map[InnovationKey]int),
+		Counter:     ihf.Counter,
+		Splits:      make(map[SplitKey]int),
+		NodeCounter: ihf.NodeCounter,
+	}
+	for _, entry := range ihf.History {
+		ih.History[InnovationKey{inNodeID: entry.InNode, outNodeID: entry.OutNode}] = entry.Innovation
+	}
+	for _, entry := range ihf.Splits {
+		ih.Splits[SplitKey{connection: entry.Connection, nth: entry.Nth}] = entry.Node
+	}
+	return ih
+}
+
+func genomToFile(genom *Genom) genomFile {
+	gf := genomFile{
+		NumInputs:        genom.NumInputs,
+		NumOutputs:       genom.NumOutputs,
+		TotalNodes:       genom.TotalNodes,
+		ConnCreationRate: genom.ConnCreationRate,
+		Fitness:          genom.Fitness,
+		Recurrent:        genom.Recurrent,
+		WeightSigma:      genom.WeightSigma,
+		Nodes:            []nodeFile{},
+		Connections:      []connectionFile{},
+	}
+	for _, node := range genom.Nodes {
+		gf.Nodes = append(gf.Nodes, nodeFile{ID: node.ID, Type: node.Type, Activation: node.Activation})
+	}
+	for _, conn := range genom.Connections {
+		gf.Connections = append(gf.Connections, connectionFile{
+			InNode:     conn.InNode.ID,
+			OutNode:    conn.OutNode.ID,
+			Weight:     conn.Weight,
+			Innovation: conn.Innovation,
+			Enabled:    conn.Enabled,
+		})
+	}
+	return gf
+}
+
+func genomFromFile(gf *genomFile, ih *InnovationHistory) (*Genom, error) {
+	genom := &Genom{
+		NumInputs:        gf.NumInputs,
+		NumOutputs:       gf.NumOutputs,
+		TotalNodes:       gf.TotalNodes,
+		ConnCreationRate: gf.ConnCreationRate,
+		Fitness:          gf.Fitness,
+		Recurrent:        gf.Recurrent,
+		WeightSigma:      gf.WeightSigma,
+		IH:               ih,
+	}
+	nodeMap := make(map[int]*Node)
+	for _, nf := range gf.Nodes {
+		node := &Node{ID: nf.ID, Type: nf.Type, Activation: nf.Activation}
+		genom.Nodes = append(genom.Nodes, node)
+		nodeMap[nf.ID] = node
+	}
+	for _, cf := range gf.Connections {
+		in, out := nodeMap[cf.InNode], nodeMap[cf.OutNode]
+		if in == nil || out == nil {
+			return nil, fmt.Errorf("connection %d: %d -> %d points at a missing node", cf.Innovation, cf.InNode, cf.OutNode)
+		}
+		conn := Connection{
+			InNode:     in,
+			OutNode:    out,
+			Weight:     cf.Weight,
+			Innovation: cf.Innovation,
+			Enabled:    cf.Enabled,
+		}
+		genom.Connections = append(genom.Connections, conn)
+		out.IncomingConns = append(out.IncomingConns, conn)
+	}
+	if err := genom.Validate(); err != nil {
+		return nil, err
+	}
+	return genom, nil
+}
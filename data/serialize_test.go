@@ -0,0 +1,141 @@
+package data
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+)
+
+// serializedPop builds a population of mutated genomes sharing one innovation history
+func serializedPop() *Population {
+	cfg := DefaultNEATConfig()
+	cfg.AddNodeRate, cfg.ToggleConnectionRate, cfg.ActivationRate = 0.5, 0.5, 0.3
+	ih := &InnovationHistory{History: make(map[InnovationKey]int)}
+	rng := rand.New(rand.NewPCG(3, 4))
+	pop := &Population{PopSize: 6, CurrentGeneration: 7, Config: cfg, NextSpeciesID: 2, Threshold: 2.5, IH: ih, Rand: rng}
+	for id := 0; id < 2; id++ {
+		species := &Species{ID: id, Age: 3 + id, BestFitness: 12, Stagnation: id, AverageFitness: 4.5, BreedingRate: 3}
+		for i := 0; i < 3; i++ {
+			genom := &Genom{NumInputs: 3, NumOutputs: 2, ConnCreationRate: 1.0, IH: ih, Rand: rng}
+			genom.CreateNetwork()
+			for j := 0; j < 30; j++ {
+				genom.Mutate(&cfg, cfg.WeightPerturbPower)
+			}
+			genom.Fitness = float64(10*id + i)
+			species.Genoms = append(species.Genoms, genom)
+		}
+		species.Representative = species.Genoms[0]
+		pop.AllSpecies = append(pop.AllSpecies, species)
+	}
+	return pop
+}
+
+func sameGenom(t *testing.T, name string, got, want *Genom) {
+	t.Helper()
+	if got.NumInputs != want.NumInputs || got.NumOutputs != want.NumOutputs || got.TotalNodes != want.TotalNodes ||
+		got.Fitness != want.Fitness || got.ConnCreationRate != want.ConnCreationRate {
+		t.Fatalf("%s: got %+v, want %+v", name, got, want)
+	}
+	if len(got.Nodes) != len(want.Nodes) {
+		t.Fatalf("%s: %d nodes, want %d", name, len(got.Nodes), len(want.Nodes))
+	}
+	for i, node := range want.Nodes {
+		if n := got.Nodes[i]; n.ID != node.ID || n.Type != node.Type {
+			t.Fatalf("%s: node %d is %+v, want %+v", name, i, n, node)
+		}
+	}
+	if len(got.Connections) != len(want.Connections) {
+		t.Fatalf("%s: %d connections, want %d", name, len(got.Connections), len(want.Connections))
+	}
+	for i, conn := range want.Connections {
+		c := got.Connections[i]
+		if c.InNode.ID != conn.InNode.ID || c.OutNode.ID != conn.OutNode.ID || c.Weight != conn.Weight ||
+			c.Innovation != conn.Innovation || c.Enabled != conn.Enabled {
+			t.Fatalf("%s: connection %d is %d->%d %v #%d %v, want %d->%d %v #%d %v", name, i,
+				c.InNode.ID, c.OutNode.ID, c.Weight, c.Innovation, c.Enabled,
+				conn.InNode.ID, conn.OutNode.ID, conn.Weight, conn.Innovation, conn.Enabled)
+		}
+	}
+}
+
+func TestPopulationRoundTrip(t *testing.T) {
+	pop := serializedPop()
+	disabled := 0
+	for _, genom := range AllGenomesFromPopulation(pop) {
+		for _, conn := range genom.Connections {
+			if !conn.Enabled {
+				disabled++
+			}
+		}
+	}
+	if disabled == 0 {
+		t.Fatal("no disabled genes to check, mutate the genomes more")
+	}
+
+	var buf bytes.Buffer
+	if err := EncodePopulation(&buf, pop); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.String()
+	loaded, err := DecodePopulation(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.PopSize != pop.PopSize || loaded.CurrentGeneration != pop.CurrentGeneration ||
+		loaded.NextSpeciesID != pop.NextSpeciesID || loaded.Threshold != pop.Threshold || loaded.Config != pop.Config {
+		t.Fatalf("population fields: got %+v", loaded)
+	}
+	if loaded.IH.Counter != pop.IH.Counter || loaded.IH.NodeCounter != pop.IH.NodeCounter ||
+		len(loaded.IH.History) != len(pop.IH.History) || len(loaded.IH.Splits) != len(pop.IH.Splits) {
+		t.Fatal("innovation history differs")
+	}
+	for key, inno := range pop.IH.History {
+		if loaded.IH.History[key] != inno {
+			t.Fatalf("innovation %v is %d, want %d", key, loaded.IH.History[key], inno)
+		}
+	}
+	for key, node := range pop.IH.Splits {
+		if loaded.IH.Splits[key] != node {
+			t.Fatalf("split %v is node %d, want %d", key, loaded.IH.Splits[key], node)
+		}
+	}
+	if len(loaded.AllSpecies) != len(pop.AllSpecies) {
+		t.Fatalf("%d species, want %d", len(loaded.AllSpecies), len(pop.AllSpecies))
+	}
+	for i, species := range pop.AllSpecies {
+		s := loaded.AllSpecies[i]
+		if s.ID != species.ID || s.Age != species.Age || s.BestFitness != species.BestFitness || s.Stagnation != species.Stagnation ||
+			s.AverageFitness != species.AverageFitness || s.BreedingRate != species.BreedingRate || len(s.Genoms) != len(species.Genoms) {
+			t.Fatalf("species %d: got %+v, want %+v", species.ID, s, species)
+		}
+		for j, genom := range species.Genoms {
+			sameGenom(t, "genom", s.Genoms[j], genom)
+			if s.Genoms[j].IH != loaded.IH {
+				t.Fatal("loaded genomes don't share the innovation history")
+			}
+		}
+		sameGenom(t, "representative", s.Representative, species.Representative)
+	}
+
+	// saving the loaded population gives the same file
+	var again bytes.Buffer
+	if err := EncodePopulation(&again, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != saved {
+		t.Fatal("the population changed after loading and saving it again")
+	}
+}
+
+func TestDecodeInvalidConfig(t *testing.T) {
+	pop := serializedPop()
+	pop.Config.SurvivalThreshold = 1.5
+	var buf bytes.Buffer
+	if err := EncodePopulation(&buf, pop); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodePopulation(&buf); err == nil {
+		t.Fatal("a population with survival_threshold 1.5 was loaded")
+	}
+}
@@ -0,0 +1,95 @@
+package data
+
+import "testing"
+
+func scoredSpecies(id int, scores ...float64) *Species {
+	species := &Species{ID: id}
+	for _, score := range scores {
+		species.Genoms = append(species.Genoms, &Genom{Fitness: score, score: score})
+	}
+	return species
+}
+
+func TestAllocateOffspring(t *testing.T) {
+	tests := []struct {
+		name    string
+		popSize int
+		species []*Species
+		want    []int
+	}{
+		{"proportional", 10, []*Species{scoredSpecies(1, 6, 6), scoredSpecies(2, 3), scoredSpecies(3, 1, 1, 1)}, []int{6, 3, 1}},
+		{"remainders", 10, []*Species{scoredSpecies(1, 1), scoredSpecies(2, 1), scoredSpecies(3, 1)}, []int{4, 3, 3}},
+		{"all zero", 9, []*Species{scoredSpecies(1, 0, 0), scoredSpecies(2, 0), scoredSpecies(3, 0, 0, 0)}, []int{3, 3, 3}},
+		{"all zero, uneven", 10, []*Species{scoredSpecies(1, 0), scoredSpecies(2, 0, 0), scoredSpecies(3, 0)}, []int{4, 3, 3}},
+		{"top species kept", 5, []*Species{scoredSpecies(1, 10, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0), scoredSpecies(2, 9, 9)}, []int{1, 4}},
+		{"more species than slots", 2, []*Species{scoredSpecies(1, 1), scoredSpecies(2, 1), scoredSpecies(3, 1)}, nil},
+	}
+	for _, tt := range tests {
+		pop := &Population{PopSize: tt.popSize, AllSpecies: tt.species}
+		pop.allocateOffspring()
+		total := 0
+		for i, species := range pop.AllSpecies {
+			if species.BreedingRate < 0 {
+				t.Fatalf("%s: species %d got %d offspring", tt.name, species.ID, species.BreedingRate)
+			}
+			if tt.want != nil && species.BreedingRate != tt.want[i] {
+				t.Errorf("%s: species %d got %d offspring, want %d", tt.name, species.ID, species.BreedingRate, tt.want[i])
+			}
+			total += species.BreedingRate
+		}
+		if total != tt.popSize {
+			t.Errorf("%s: %d offspring in total, want %d", tt.name, total, tt.popSize)
+		}
+	}
+}
+
+func TestStagnantSpeciesCulled(t *testing.T) {
+	// species 1 hasn't improved for StagnationLimit generations, species 2 has the best genome now
+	cfg := DefaultNEATConfig()
+	cfg.StagnationLimit = 3
+	old, young := chainGenom(1), chainGenom(3)
+	pop := &Population{
+		PopSize:   10,
+		Config:    cfg,
+		Threshold: 0.5,
+		AllSpecies: []*Species{
+			{ID: 1, Representative: old, Age: 5, BestFitness: 100, Stagnation: 2, BreedingRate: 5},
+			{ID: 2, Representative: young, Age: 1, BestFitness: 1, BreedingRate: 5},
+		},
+		NextSpeciesID: 3,
+		Rand:          old.Rand,
+	}
+	stagnant := pop.AllSpecies[0]
+	genoms := []*Genom{}
+	for i, fitness := range []float64{10, 10, 50, 40} {
+		genom := CloneGenom(old)
+		if i >= 2 {
+			genom = CloneGenom(young)
+		}
+		genom.Fitness = fitness
+		genoms = append(genoms, genom)
+	}
+
+	pop.Speciate(genoms)
+	pop.scoreGenomes()
+	pop.allocateOffspring()
+	if len(pop.AllSpecies) != 1 || pop.AllSpecies[0].ID != 2 {
+		t.Fatalf("species left: %d, want only species 2", len(pop.AllSpecies))
+	}
+	if stagnant.BreedingRate != 0 {
+		t.Fatalf("culled species keeps %d offspring, want 0", stagnant.BreedingRate)
+	}
+	if got := pop.AllSpecies[0].BreedingRate; got != pop.PopSize {
+		t.Fatalf("the remaining species got %d offspring, want %d", got, pop.PopSize)
+	}
+	if species := pop.AllSpecies[0]; species.Stagnation != 0 || species.BestFitness != 50 || species.Age != 2 {
+		t.Fatalf("species 2: stagnation %d, best fitness %v, age %d", species.Stagnation, species.BestFitness, species.Age)
+	}
+
+	// the species with the best genome survives however long it stagnates
+	pop.AllSpecies[0].Stagnation = 10
+	pop.Speciate(genoms[2:])
+	if len(pop.AllSpecies) != 1 {
+		t.Fatal("the top species was culled")
+	}
+}
@@ -0,0 +1,176 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// – – – – – – – – – – – – – – – – GENOME VALIDATION – – – – – – – – – – – – – – – – – – – –
+// a genome is rebuilt by hand in a few places (cloning, crossover, loading),
+// a mistake there used to show up only later as a nil pointer panic in Forward
+
+func (genom *Genom) Validate() error {
+	// checks the structure of the genome, returns every problem found (nil if there are none)
+	// Connections are the source of truth: IncomingConns must hold copies of the same connections, in genome order
+	var problems []error
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	nodes := make(map[int]*Node)
+	inputs, outputs, biases, maxID := 0, 0, 0, -1
+	for i, node := range genom.Nodes {
+		if node == nil {
+			fail("node %d is nil", i)
+			continue
+		}
+		if _, dup := nodes[node.ID]; dup {
+			fail("duplicate node %d", node.ID)
+			continue
+		}
+		nodes[node.ID] = node
+		switch node.Type {
+		case Input:
+			inputs++
+		case Output:
+			outputs++
+		case Bias:
+			biases++
+		}
+		if node.ID > maxID {
+			maxID = node.ID
+		}
+	}
+	if inputs != genom.NumInputs {
+		fail("NumInputs is %d, but the genome has %d input nodes", genom.NumInputs, inputs)
+	}
+	if outputs != genom.NumOutputs {
+		fail("NumOutputs is %d, but the genome has %d output nodes", genom.NumOutputs, outputs)
+	}
+	if biases > 1 {
+		fail("the genome has %d bias nodes", biases)
+	}
+	if genom.TotalNodes != maxID+1 {
+		fail("TotalNodes is %d, but the highest node ID is %d", genom.TotalNodes, maxID)
+	}
+
+	innovations := make(map[int]bool)
+	incoming := make(map[int][]Connection)
+	for _, conn := range genom.Connections {
+		if innovations[conn.Innovation] {
+			fail("duplicate innovation %d", conn.Innovation)
+		}
+		innovations[conn.Innovation] = true
+		if conn.InNode == nil || conn.OutNode == nil {
+			fail("connection %d has a nil node", conn.Innovation)
+			continue
+		}
+		if nodes[conn.InNode.ID] != conn.InNode {
+			fail("connection %d: node %d is missing from Nodes", conn.Innovation, conn.InNode.ID)
+		}
+		if nodes[conn.OutNode.ID] != conn.OutNode {
+			fail("connection %d: node %d is missing from Nodes", conn.Innovation, conn.OutNode.ID)
+		}
+		if conn.OutNode.Type == Input || conn.OutNode.Type == Bias {
+			fail("connection %d: %s node %d is a target", conn.Innovation, strings.ToLower(conn.OutNode.Type.String()), conn.OutNode.ID)
+		}
+		if conn.InNode.Type == Output && !genom.Recurrent {
+			fail("connection %d: output node %d is a source", conn.Innovation, conn.InNode.ID)
+		}
+		incoming[conn.OutNode.ID] = append(incoming[conn.OutNode.ID], conn)
+	}
+
+	if !genom.Recurrent {
+		// a feed-forward genome is evaluated in one pass, a cycle would be left out of it
+		back := []int{}
+		for innovation := range genom.cycleEdges() {
+			back = append(back, innovation)
+		}
+		sort.Ints(back)
+		for _, innovation := range back {
+			fail("connection %d closes a cycle in a non-recurrent genome", innovation)
+		}
+	}
+
+	for _, node := range genom.Nodes {
+		if node == nil || nodes[node.ID] != node {
+			continue
+		}
+		if !sameConnections(node.IncomingConns, incoming[node.ID]) {
+			fail("IncomingConns of node %d don't match Connections", node.ID)
+		}
+	}
+	return errors.Join(problems...)
+}
+
+func (genom *Genom) Repair() {
+	// fixes whatever Validate would complain about:
+	// drops duplicate (and extra bias) nodes, connections pointing at missing nodes, duplicate innovations
+	// and connections going the wrong way, then rebuilds IncomingConns, NumInputs, NumOutputs and TotalNodes
+	// connections closing a cycle in a non-recurrent genome are disabled
+	nodes := make(map[int]*Node)
+	kept := genom.Nodes[:0]
+	genom.NumInputs, genom.NumOutputs, genom.TotalNodes = 0, 0, 0
+	bias := false
+	for _, node := range genom.Nodes {
+		if node == nil || nodes[node.ID] != nil || (node.Type == Bias && bias) {
+			continue
+		}
+		nodes[node.ID] = node
+		node.IncomingConns = nil
+		kept = append(kept, node)
+		switch node.Type {
+		case Input:
+			genom.NumInputs++
+		case Output:
+			genom.NumOutputs++
+		case Bias:
+			bias = true
+		}
+		if node.ID >= genom.TotalNodes {
+			genom.TotalNodes = node.ID + 1
+		}
+	}
+	genom.Nodes = kept
+
+	innovations := make(map[int]bool)
+	conns := genom.Connections[:0]
+	for _, conn := range genom.Connections {
+		if conn.InNode == nil || conn.OutNode == nil || innovations[conn.Innovation] {
+			continue
+		}
+		// connections may point at a copy of the node, the genome's own one is used instead
+		in, out := nodes[conn.InNode.ID], nodes[conn.OutNode.ID]
+		if in == nil || out == nil || out.Type == Input || out.Type == Bias || (in.Type == Output && !genom.Recurrent) {
+			continue
+		}
+		innovations[conn.Innovation] = true
+		conn.InNode, conn.OutNode = in, out
+		conns = append(conns, conn)
+		out.IncomingConns = append(out.IncomingConns, conn)
+	}
+	genom.Connections = conns
+	genom.net = nil
+	if !genom.Recurrent {
+		genom.disableCycles()
+	}
+}
+
+func sameConnections(got, want []Connection) bool {
+	// helper function
+	// compares lists of connections by innovation, node IDs, weight and enabled flag
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Innovation != want[i].Innovation ||
+			got[i].InNode == nil || got[i].OutNode == nil ||
+			got[i].InNode.ID != want[i].InNode.ID || got[i].OutNode.ID != want[i].OutNode.ID ||
+			got[i].Weight != want[i].Weight || got[i].Enabled != want[i].Enabled {
+			return false
+		}
+	}
+	return true
+}
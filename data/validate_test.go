@@ -0,0 +1,128 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateValid(t *testing.T) {
+	genom := validGenom()
+	if err := genom.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloneGenom(genom).Validate(); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+}
+
+func TestValidateRepair(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(*Genom)
+		want    string
+	}{
+		{"missing node", func(g *Genom) {
+			g.Connections[0].InNode = &Node{ID: 100, Type: Hidden}
+		}, "missing from Nodes"},
+		{"copied node", func(g *Genom) {
+			in := *g.Connections[0].InNode
+			g.Connections[0].InNode = &in
+		}, "missing from Nodes"},
+		{"duplicate innovation", func(g *Genom) {
+			g.Connections = append(g.Connections, g.Connections[0])
+		}, "duplicate innovation"},
+		{"stale incoming", func(g *Genom) {
+			out := g.Connections[0].OutNode
+			out.IncomingConns = out.IncomingConns[1:]
+		}, "IncomingConns"},
+		{"input as target", func(g *Genom) {
+			g.addConnetion(g.Nodes[1], g.Nodes[0], 1, true)
+		}, "is a target"},
+		{"bias as target", func(g *Genom) {
+			g.addConnetion(g.Nodes[1], g.Nodes[g.NumInputs+g.NumOutputs], 1, true)
+		}, "bias node"},
+		{"two biases", func(g *Genom) {
+			extra := &Node{ID: g.TotalNodes, Type: Bias}
+			g.Nodes = append(g.Nodes, extra)
+			g.TotalNodes++
+			g.addConnetion(extra, g.Nodes[g.NumInputs], 1, true)
+		}, "bias nodes"},
+		{"output as source", func(g *Genom) {
+			g.addConnetion(g.Nodes[g.NumInputs], g.Nodes[len(g.Nodes)-1], 1, true)
+		}, "is a source"},
+		{"stale weight", func(g *Genom) {
+			g.Connections[0].Weight += 1
+		}, "IncomingConns"},
+		{"self-loop", func(g *Genom) {
+			hidden := g.Nodes[len(g.Nodes)-1]
+			g.addConnetion(hidden, hidden, 1, true)
+		}, "closes a cycle"},
+		{"cycle", func(g *Genom) {
+			// a connection back from the target to the source of an enabled one
+			for _, conn := range g.Connections {
+				if conn.Enabled && conn.InNode.Type == Hidden && conn.OutNode.Type == Hidden && conn.InNode != conn.OutNode {
+					g.addConnetion(conn.OutNode, conn.InNode, 1, true)
+					return
+				}
+			}
+			panic("validGenom has no hidden -> hidden connection")
+		}, "closes a cycle"},
+		{"node counts", func(g *Genom) {
+			g.NumInputs++
+			g.NumOutputs--
+		}, "NumOutputs"},
+		{"total nodes", func(g *Genom) {
+			g.TotalNodes += 5
+		}, "TotalNodes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genom := validGenom()
+			tt.corrupt(genom)
+			err := genom.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Validate() = %v, want an error about %q", err, tt.want)
+			}
+			genom.Repair()
+			if err := genom.Validate(); err != nil {
+				t.Fatalf("after Repair: %v", err)
+			}
+			genom.Forward(make([]float64, genom.NumInputs))
+		})
+	}
+}
+
+func TestValidateRecurrentOutputSource(t *testing.T) {
+	genom := validGenom()
+	genom.Recurrent = true
+	genom.addConnetion(genom.Nodes[genom.NumInputs], genom.Nodes[len(genom.Nodes)-1], 1, true)
+	if err := genom.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRecurrentCycle(t *testing.T) {
+	genom := validGenom()
+	genom.Recurrent = true
+	hidden := genom.Nodes[len(genom.Nodes)-1]
+	genom.addConnetion(hidden, hidden, 1, true)
+	if err := genom.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	genom.Repair()
+	if !genom.Connections[len(genom.Connections)-1].Enabled {
+		t.Fatal("Repair disabled a self-loop of a recurrent genome")
+	}
+}
+
+func TestMutateKeepsIncomingInSync(t *testing.T) {
+	cfg := DefaultNEATConfig()
+	cfg.AddConnectionRate, cfg.AddNodeRate, cfg.ToggleConnectionRate = 0.5, 0.5, 0.5
+	genom := validGenom()
+	for i := 0; i < 100; i++ {
+		genom.Mutate(&cfg, cfg.WeightPerturbPower)
+		if err := genom.Validate(); err != nil {
+			t.Fatalf("mutation %d: %v", i, err)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package entities
 
 import (
-	"math"
-	"math/rand/v2"
 	"projectEVA/animations"
 	"projectEVA/components"
 )
@@ -26,52 +24,6 @@ type Enemy struct {
 	Speed      float64
 }
 
-var directions = [2]int{-1, 1}
-
-func (e *Enemy) FollowsTarget(target *Sprite, vision float64) {
-	if math.Abs(target.X-e.Sprite.X) < vision && math.Abs(target.Y-e.Sprite.Y) < vision {
-		if e.Sprite.X < target.X {
-			e.Sprite.Dx = 1
-		} else if e.Sprite.X > target.X {
-			e.Sprite.Dx = -1
-		}
-		if e.Sprite.Y < target.Y {
-			e.Sprite.Dy = 1
-		} else if e.Sprite.Y > target.Y {
-			e.Sprite.Dy = -1
-		}
-	} else {
-		steps := rand.IntN(7)
-		if steps == 0 {
-			e.Sprite.Dx = (0.1 + 2*(math.Log(1+e.Speed)))
-		}
-		if steps == 1 {
-			e.Sprite.Dx = -(0.1 + 2*(math.Log(1+e.Speed)))
-		}
-		if steps == 2 {
-			e.Sprite.Dy = -(0.1 + 2*(math.Log(1+e.Speed)))
-		}
-		if steps == 3 {
-			e.Sprite.Dy = (0.1 + 2*(math.Log(1+e.Speed)))
-		}
-		if steps == 4 {
-			e.Sprite.Dx = (0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-			e.Sprite.Dy = -(0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-		}
-		if steps == 5 {
-			e.Sprite.Dx = -(0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-			e.Sprite.Dy = -(0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-		}
-		if steps == 6 {
-			e.Sprite.Dx = (0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-			e.Sprite.Dy = (0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-		}
-		if steps == 7 {
-			e.Sprite.Dx = -(0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-			e.Sprite.Dy = (0.1 + 2*(math.Log(1+e.Speed))) / 1.4
-		}
-	}
-}
 func (e *Enemy) ActiveAnimation(eType int) *animations.Animation {
 	if eType == 0 {
 		return e.Animations[Meat]
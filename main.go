@@ -1,13 +1,18 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"projectEVA/constants"
+	"projectEVA/scenes"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
+	flag.StringVar(&scenes.ChampionsPath, "champions", scenes.ChampionsPath, "champion archive watched after pressing H (e.g. <out>/champions.json of a headless run)")
+	flag.Parse()
+
 	ebiten.SetWindowSize(constants.WindowWidth, constants.WindowHeight)
 	ebiten.SetWindowTitle("ProjectEVA")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
@@ -0,0 +1,41 @@
+package sim
+
+import (
+	"projectEVA/constants"
+)
+
+// BehaviourGrid is the number of map cells per side counted by the visited-cell histogram
+const BehaviourGrid = 4
+
+// BehaviourSize is the length of the vector Behaviour returns
+const BehaviourSize = 2 + BehaviourGrid*BehaviourGrid + 3
+
+// Behaviour describes what the player did in the episode, for novelty search:
+// final position (as a fraction of the map size), part of the lived frames spent in every
+// cell of a BehaviourGrid x BehaviourGrid histogram of the map, and the mix of food eaten,
+// enemies killed and vitamins taken (as fractions of all interactions), all values in [0, 1]
+func (w *World) Behaviour() []float64 {
+	b := make([]float64, 0, BehaviourSize)
+	b = append(b, w.Player.X/constants.GameWidth, w.Player.Y/constants.GameHeight)
+	for _, frames := range w.visited {
+		if w.episode.FramesLived > 0 {
+			b = append(b, float64(frames)/float64(w.episode.FramesLived))
+		} else {
+			b = append(b, 0)
+		}
+	}
+	food, enemies, vitamins := float64(w.episode.TotalFoodEaten), float64(w.episode.TotalEnemiesKilled), float64(w.episode.VitaminsTaken)
+	if total := food + enemies + vitamins; total > 0 {
+		b = append(b, food/total, enemies/total, vitamins/total)
+	} else {
+		b = append(b, 0, 0, 0)
+	}
+	return b
+}
+
+func (w *World) visit() {
+	// counts the frame in the cell the player is in
+	col := min(max(int(w.Player.X*BehaviourGrid/constants.GameWidth), 0), BehaviourGrid-1)
+	row := min(max(int(w.Player.Y*BehaviourGrid/constants.GameHeight), 0), BehaviourGrid-1)
+	w.visited[row*BehaviourGrid+col]++
+}
@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"math"
+	"projectEVA/constants"
+	"projectEVA/data"
+	"sync"
+)
+
+// NumInputs and NumOutputs describe the network shape the world expects
+const (
+	NumInputs  = 15
+	NumOutputs = 2
+)
+
+// InputNames and OutputNames describe what every input and output of a genom means,
+// in the order Inputs and SteerByOutputs use
+var (
+	InputNames = []string{
+		"score", "hp", "damage", "speed", "efficiency", "x", "y", "calories",
+		"nearest food distance", "nearest food angle",
+		"nearest vitamin distance", "nearest vitamin angle",
+		"nearest enemy distance", "nearest enemy angle", "nearest enemy hp",
+	}
+	OutputNames = []string{"dx", "dy"}
+)
+
+// Inputs prepares the input vector for NEAT from what the player currently sees
+func (w *World) Inputs() []float64 {
+	player := w.Player
+	inputs := []float64{
+		math.Tanh(float64(w.Score) / 100.0),
+		math.Tanh(player.CombatComp.Health() / 10.0),
+		math.Tanh(player.Dmg / 10.0),
+		math.Tanh(player.Speed / 10.0),
+		math.Tanh(player.Efficiency / 10.0),
+		player.X / float64(constants.GameWidth),
+		player.Y / float64(constants.GameHeight),
+		player.Calories / 1000.0,
+	}
+
+	if len(w.NearFoods) > 0 {
+		distance := math.Min(w.NearFoods[0][0]/500.0, 1.0)
+		angle := normalizer(w.NearFoods[0][1])
+		inputs = append(inputs, distance, angle)
+	} else {
+		inputs = append(inputs, 1.0, 0.0)
+	}
+
+	if len(w.NearVitamins) > 0 {
+		distance := math.Min(w.NearVitamins[0][0]/500.0, 1.0)
+		angle := normalizer(w.NearVitamins[0][1])
+		inputs = append(inputs, distance, angle)
+	} else {
+		inputs = append(inputs, 1.0, 0.0)
+	}
+
+	if len(w.NearEnemies) > 0 {
+		distance := math.Min(w.NearEnemies[0][0]/500.0, 1.0)
+		angle := normalizer(w.NearEnemies[0][1])
+		enemyHP := math.Min(w.NearEnemies[0][2]/10.0, 1.0)
+		inputs = append(inputs, distance, angle, enemyHP)
+	} else {
+		inputs = append(inputs, 1.0, 0.0, 0.0)
+	}
+	return inputs
+}
+
+func normalizer(raw float64) float64 {
+	return (raw + 180) / 360
+}
+
+// SteerByKeys sets the player's direction from the pressed movement keys
+func (w *World) SteerByKeys(up, left, down, right bool) {
+	player := w.Player
+	v := player.moveScale()
+	player.Dx = 0.0
+	player.Dy = 0.0
+	if right && (!up && !down) {
+		player.Dx = v
+	}
+	if left && (!up && !down) {
+		player.Dx = -v
+	}
+	if up && (!right && !left) {
+		player.Dy = -v
+	}
+	if down && (!left && !right) {
+		player.Dy = v
+	}
+	if up && right {
+		player.Dx = v / 1.4
+		player.Dy = -v / 1.4
+	}
+	if up && left {
+		player.Dx = -v / 1.4
+		player.Dy = -v / 1.4
+	}
+	if down && right {
+		player.Dx = v / 1.4
+		player.Dy = v / 1.4
+	}
+	if down && left {
+		player.Dx = -v / 1.4
+		player.Dy = v / 1.4
+	}
+}
+
+// SteerByOutputs sets the player's direction from the network outputs (dx, dy in [-1,1])
+func (w *World) SteerByOutputs(outputs []float64) {
+	if len(outputs) < 2 {
+		return
+	}
+	player := w.Player
+	moveScale := (0.1 + 2.5*math.Log(1+player.Speed)) * player.SpeedMultiplier
+
+	dx := outputs[0] * moveScale
+	dy := outputs[1] * moveScale
+
+	// dead zone, so the player doesn't jitter on small values
+	threshold := 0.05
+	if math.Abs(dx) < threshold {
+		dx = 0
+	}
+	if math.Abs(dy) < threshold {
+		dy = 0
+	}
+
+	player.Dx = dx
+	player.Dy = dy
+}
+
+// ControlBy lets genom decide where the player goes this frame
+// and explains the decision (for the AI panel of the game window),
+// with Config.Retina the explanation is just the retina and the movement it led to
+func (w *World) ControlBy(genom *data.Genom) data.AIDecision {
+	if r := w.retina(); r != nil {
+		// painting the substrate takes a CPPN query per connection, so it is done once per genom
+		if w.retinaOf != genom || w.retinaNet == nil {
+			w.retinaNet = r.Substrate().Build(genom)
+			w.retinaOf = genom
+		}
+		inputs := w.RetinaInputs(r)
+		outputs := r.Steer(w.retinaNet.Activate(inputs))
+		w.SteerByOutputs(outputs)
+		return data.AIDecision{Inputs: inputs, Outputs: outputs}
+	}
+	outputs, decision := genom.Forward(w.Inputs())
+	w.SteerByOutputs(outputs)
+	return decision
+}
+
+// Play lets genom control the player until its life is over and returns its fitness.
+// The genom is compiled once per episode, so a recurrent genom starts without memory.
+func (w *World) Play(genom *data.Genom) float64 {
+	if r := w.retina(); r != nil {
+		net := r.Substrate().Build(genom)
+		for !w.Done() {
+			w.SteerByOutputs(r.Steer(net.Activate(w.RetinaInputs(r))))
+			w.Step()
+		}
+		return w.EvaluateFitness(genom)
+	}
+	net := genom.Compile()
+	for !w.Done() {
+		w.SteerByOutputs(net.Activate(w.Inputs()))
+		w.Step()
+	}
+	return w.EvaluateFitness(genom)
+}
+
+// Evaluate plays a whole episode with genom in control and returns its fitness
+func Evaluate(genom *data.Genom, cfg Config) float64 {
+	return NewWorld(cfg).Play(genom)
+}
+
+// EvaluateAll evaluates genoms concurrently on the given number of workers.
+// Every worker steps its own world, and seeds[i] seeds the episode of genoms[i],
+// so the results don't depend on which worker picked a genom up.
+func EvaluateAll(genoms []*data.Genom, cfg Config, seeds []uint64, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := NewWorld(cfg)
+			for j := range jobs {
+				w.Restart(seeds[j])
+				w.Play(genoms[j])
+			}
+		}()
+	}
+	for j := range genoms {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+}
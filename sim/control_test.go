@@ -0,0 +1,51 @@
+package sim
+
+import (
+	"math/rand/v2"
+	"projectEVA/data"
+	"testing"
+)
+
+func testGenoms(n int) []*data.Genom {
+	ih := &data.InnovationHistory{History: make(map[data.InnovationKey]int)}
+	rng := rand.New(rand.NewPCG(1, 0))
+	genoms := []*data.Genom{}
+	for i := 0; i < n; i++ {
+		genom := &data.Genom{NumInputs: NumInputs, NumOutputs: NumOutputs, ConnCreationRate: 1.0, IH: ih, Rand: rng}
+		genom.CreateNetwork()
+		genoms = append(genoms, genom)
+	}
+	return genoms
+}
+
+func TestEvaluateAllWorkers(t *testing.T) {
+	// every genom gets its own seed, so the number of workers doesn't change any fitness
+	genoms := testGenoms(12)
+	seeds := make([]uint64, len(genoms))
+	for i := range seeds {
+		seeds[i] = uint64(100 + i)
+	}
+	cfg := Config{Diet: 0, LifetimeFrames: 300}
+	evaluate := func(workers int) []float64 {
+		fitness := []float64{}
+		clones := []*data.Genom{}
+		for _, genom := range genoms {
+			clones = append(clones, data.CloneGenom(genom))
+		}
+		EvaluateAll(clones, cfg, seeds, workers)
+		for _, genom := range clones {
+			fitness = append(fitness, genom.Fitness)
+		}
+		return fitness
+	}
+
+	want := evaluate(1)
+	for _, workers := range []int{2, 6} {
+		got := evaluate(workers)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%d workers: genom %d has fitness %v, with 1 worker %v", workers, i, got[i], want[i])
+			}
+		}
+	}
+}
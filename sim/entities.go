@@ -0,0 +1,177 @@
+package sim
+
+import (
+	"image"
+	"math"
+	"math/rand/v2"
+	"projectEVA/components"
+	"projectEVA/constants"
+)
+
+// Body is the part of a sprite the simulation cares about: where it is,
+// where it is going and how big it is. Images and animations stay in scenes.
+type Body struct {
+	X, Y, Dx, Dy float64
+	Size         float64
+}
+
+// Rect returns the hitbox of the body
+func (b *Body) Rect() image.Rectangle {
+	return image.Rect(
+		int(b.X),
+		int(b.Y),
+		int(b.X+(constants.Tilesize*b.Size)),
+		int(b.Y+(constants.Tilesize*b.Size)),
+	)
+}
+
+// wrap teleports the body to the other side of the map when it crosses an edge
+func (b *Body) wrap() {
+	if b.X >= constants.GameWidth {
+		b.X = 1
+	}
+	if b.X <= 0 {
+		b.X = constants.GameWidth - 1
+	}
+	if b.Y >= constants.GameHeight {
+		b.Y = 1
+	}
+	if b.Y <= 0 {
+		b.Y = constants.GameHeight - 1
+	}
+}
+
+type Player struct {
+	Body
+	Calories             float64
+	Speed                float64
+	Efficiency           float64
+	SpeedMultiplier      float64
+	EfficiencyMultiplier float64
+	TempHP               float64
+	Diet                 int
+	CombatComp           *components.PlayerCombat
+	Dmg                  float64
+	MaxHealth            float64
+}
+
+// NewPlayer creates the player in the middle of the map with starting stats
+func NewPlayer(diet int) *Player {
+	return &Player{
+		Body: Body{
+			X:    (constants.GameWidth / 2) + 16,
+			Y:    (constants.GameHeight / 2) + 16,
+			Size: 1,
+		},
+		Calories:             constants.StartingCalories,
+		Speed:                5,
+		Efficiency:           1,
+		SpeedMultiplier:      1,
+		EfficiencyMultiplier: 1,
+		TempHP:               0,
+		Diet:                 diet,
+		CombatComp:           components.NewPlayerCombat(3, 1, 6000),
+		Dmg:                  1,
+		MaxHealth:            3,
+	}
+}
+
+// moveScale is how far the player moves in one frame when going straight
+func (p *Player) moveScale() float64 {
+	return (0.1 + 2*(math.Log(1+p.Speed))) * p.SpeedMultiplier
+}
+
+// canEat reports whether enemy is food for the player's diet
+func (p *Player) canEat(enemy *Enemy) bool {
+	return (enemy.Type == 0 && (p.Diet == 0 || p.Diet == 2)) || (enemy.Type == 1 && (p.Diet == 1 || p.Diet == 2))
+}
+
+// Enemy is anything living on the map which isn't the player
+// Type 0 is meat, type 1 is plant and type 2 is an aggressive enemy
+type Enemy struct {
+	Body
+	Follows    bool
+	CombatComp *components.EnemyCombat
+	Type       int
+	Speed      float64
+}
+
+func (e *Enemy) FollowsTarget(target *Body, vision float64, rng *rand.Rand) {
+	if math.Abs(target.X-e.X) < vision && math.Abs(target.Y-e.Y) < vision {
+		if e.X < target.X {
+			e.Dx = 1
+		} else if e.X > target.X {
+			e.Dx = -1
+		}
+		if e.Y < target.Y {
+			e.Dy = 1
+		} else if e.Y > target.Y {
+			e.Dy = -1
+		}
+	} else {
+		step := 0.1 + 2*(math.Log(1+e.Speed))
+		switch rng.IntN(7) {
+		case 0:
+			e.Dx = step
+		case 1:
+			e.Dx = -step
+		case 2:
+			e.Dy = -step
+		case 3:
+			e.Dy = step
+		case 4:
+			e.Dx = step / 1.4
+			e.Dy = -step / 1.4
+		case 5:
+			e.Dx = -step / 1.4
+			e.Dy = -step / 1.4
+		case 6:
+			e.Dx = step / 1.4
+			e.Dy = step / 1.4
+		}
+	}
+}
+
+type Vitamin struct {
+	Body
+	Speed, Efficiency, TempHP, Duration float64
+	StopCalory                          bool
+	Type                                int
+	CombatComp                          *components.EnemyCombat
+}
+
+func CheckCollisionHorizontal(body *Body, colliders []image.Rectangle) {
+	for _, collider := range colliders {
+		if collider.Overlaps(image.Rect(
+			int(body.X),
+			int(body.Y),
+			int(body.X)+constants.Tilesize,
+			int(body.Y)+constants.Tilesize)) {
+			if body.Dx > 0.0 {
+				body.X = float64(collider.Min.X) - constants.Tilesize
+			} else if body.Dx < 0.0 {
+				body.X = float64(collider.Max.X)
+			}
+		}
+	}
+}
+
+func CheckCollisionVertical(body *Body, colliders []image.Rectangle) {
+	for _, collider := range colliders {
+		if collider.Overlaps(image.Rect(
+			int(body.X),
+			int(body.Y),
+			int(body.X)+constants.Tilesize,
+			int(body.Y)+constants.Tilesize)) {
+			if body.Dy > 0.0 {
+				body.Y = float64(collider.Min.Y) - constants.Tilesize
+			} else if body.Dy < 0.0 {
+				body.Y = float64(collider.Max.Y)
+			}
+		}
+	}
+}
+
+func randRange(rng *rand.Rand, min, max int) int {
+	return rng.IntN(max-min) + min
+}
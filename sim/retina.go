@@ -0,0 +1,145 @@
+package sim
+
+import (
+	"math"
+	"projectEVA/data"
+)
+
+// Retina is the HyperNEAT view of the world: a grid of cells around the player
+// counting food, enemies and vitamins, wired through a data.Substrate painted by the
+// evolved CPPN to a grid of movement outputs, every one pulling towards its own cell
+type Retina struct {
+	Size   int     // cells per side of the sensor grid (for every kind of thing)
+	Radius float64 // how far the grid reaches from the player, in pixels
+	Moves  int     // cells per side of the movement grid
+
+	// built on first use and reused every frame, so a retina belongs to a single world (see World.retina)
+	substrate *data.Substrate
+	moves     []data.SubstratePoint
+	inputs    []float64
+	steer     []float64
+}
+
+// DefaultRetina sees about as far as the enemies do
+var DefaultRetina = Retina{Size: 7, Radius: 700, Moves: 3}
+
+// sensor sheets of the retina, in input order
+const (
+	retinaFood = iota
+	retinaEnemies
+	retinaVitamins
+	retinaSheets
+)
+
+// NumRetinaInputs is the length of the vector RetinaInputs returns
+func (r *Retina) NumRetinaInputs() int {
+	return retinaSheets * r.Size * r.Size
+}
+
+// Substrate places the food, enemy and vitamin grids on top of each other (Z -1, 0, 1),
+// the CPPN sees where a sensor cell is and which movement cell it is wired to
+// it is built once, every call returns the same substrate
+func (r *Retina) Substrate() *data.Substrate {
+	if r.substrate != nil {
+		return r.substrate
+	}
+	inputs := []data.SubstratePoint{}
+	for sheet := 0; sheet < retinaSheets; sheet++ {
+		inputs = append(inputs, data.GridPoints(r.Size, float64(sheet-1))...)
+	}
+	r.substrate = &data.Substrate{
+		Inputs:          inputs,
+		Outputs:         r.movePoints(),
+		WeightThreshold: data.DefaultWeightThreshold,
+		MaxWeight:       data.DefaultSubstrateWeight,
+	}
+	return r.substrate
+}
+
+func (r *Retina) movePoints() []data.SubstratePoint {
+	// helper function
+	// the movement grid, laid out once
+	if r.moves == nil {
+		r.moves = data.GridPoints(r.Moves, 0)
+	}
+	return r.moves
+}
+
+// retina is the world's own copy of Config.Retina, made again when Config.Retina is replaced
+func (w *World) retina() *Retina {
+	if w.Config.Retina == nil {
+		return nil
+	}
+	if w.retinaFrom != w.Config.Retina {
+		r := w.Config.Retina
+		w.ownRetina = &Retina{Size: r.Size, Radius: r.Radius, Moves: r.Moves}
+		w.retinaFrom = r
+		w.retinaNet = nil
+	}
+	return w.ownRetina
+}
+
+// RetinaInputs counts what is in every cell of the retina (squashed with tanh),
+// the food grid first, then enemies and vitamins, every grid row by row from the top left
+// the slice is reused by the next call
+func (w *World) RetinaInputs(r *Retina) []float64 {
+	player := w.Player
+	if len(r.inputs) != r.NumRetinaInputs() {
+		r.inputs = make([]float64, r.NumRetinaInputs())
+	}
+	inputs := r.inputs
+	clear(inputs)
+	cells := r.Size * r.Size
+	see := func(sheet int, body *Body) {
+		x := (body.X - player.X) / r.Radius
+		y := (body.Y - player.Y) / r.Radius
+		if math.Abs(x) >= 1 || math.Abs(y) >= 1 {
+			return
+		}
+		col := int((x + 1) / 2 * float64(r.Size))
+		row := int((y + 1) / 2 * float64(r.Size))
+		inputs[sheet*cells+row*r.Size+col]++
+	}
+	for _, enemy := range w.Enemies {
+		switch {
+		case enemy.Type == 2:
+			see(retinaEnemies, &enemy.Body)
+		case player.canEat(enemy):
+			see(retinaFood, &enemy.Body)
+		}
+	}
+	for _, vitamin := range w.Vitamins {
+		see(retinaVitamins, &vitamin.Body)
+	}
+	for i, n := range inputs {
+		inputs[i] = math.Tanh(n)
+	}
+	return inputs
+}
+
+// Steer turns the movement grid into dx and dy in [-1, 1]:
+// every output pushes towards its cell (or away from it, when negative)
+// the slice is reused by the next call
+func (r *Retina) Steer(outputs []float64) []float64 {
+	dx, dy, wx, wy := 0.0, 0.0, 0.0, 0.0
+	for i, p := range r.movePoints() {
+		if i >= len(outputs) {
+			break
+		}
+		dx += outputs[i] * p.X
+		dy += outputs[i] * p.Y
+		wx += math.Abs(p.X)
+		wy += math.Abs(p.Y)
+	}
+	if wx > 0 {
+		dx /= wx
+	}
+	if wy > 0 {
+		dy /= wy
+	}
+	if r.steer == nil {
+		r.steer = make([]float64, 2)
+	}
+	r.steer[0], r.steer[1] = dx, dy
+	return r.steer
+}
@@ -0,0 +1,84 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRetinaInputs(t *testing.T) {
+	r := &Retina{Size: 3, Radius: 300, Moves: 3}
+	w := NewWorld(Config{Diet: 1, LifetimeFrames: 100})
+	x, y := w.Player.X, w.Player.Y
+	w.Enemies = []*Enemy{
+		{Body: Body{X: x - 200, Y: y - 200}, Type: 1}, // a plant, top left cell of the food grid
+		{Body: Body{X: x + 200, Y: y}, Type: 2},       // middle right cell of the enemy grid
+		{Body: Body{X: x, Y: y}, Type: 0},             // meat, not food for a herbivore
+		{Body: Body{X: x + 400, Y: y}, Type: 2},       // out of sight
+	}
+	w.Vitamins = []*Vitamin{
+		{Body: Body{X: x, Y: y + 200}}, // bottom middle cell of the vitamin grid, twice
+		{Body: Body{X: x, Y: y + 200}},
+	}
+
+	inputs := w.RetinaInputs(r)
+	if len(inputs) != r.NumRetinaInputs() {
+		t.Fatalf("%d inputs, want %d", len(inputs), r.NumRetinaInputs())
+	}
+	want := map[int]float64{
+		0:          math.Tanh(1),
+		9 + 3 + 2:  math.Tanh(1),
+		18 + 6 + 1: math.Tanh(2),
+	}
+	for i, v := range inputs {
+		if v != want[i] {
+			t.Errorf("input %d = %v, want %v", i, v, want[i])
+		}
+	}
+
+	// the buffer is reused and cleared between calls
+	w.Enemies, w.Vitamins = nil, nil
+	again := w.RetinaInputs(r)
+	if &again[0] != &inputs[0] {
+		t.Fatal("RetinaInputs allocated a new slice")
+	}
+	for i, v := range again {
+		if v != 0 {
+			t.Fatalf("input %d = %v on an empty map", i, v)
+		}
+	}
+}
+
+func TestRetinaSteer(t *testing.T) {
+	r := &Retina{Size: 3, Radius: 300, Moves: 3}
+	points := r.Substrate().Outputs
+	tests := []struct {
+		name   string
+		output func(x, y float64) float64
+		dx, dy float64
+	}{
+		{"right", func(x, y float64) float64 { return x }, 1, 0},
+		{"up", func(x, y float64) float64 { return -y }, 0, -1},
+		{"down right, at half speed", func(x, y float64) float64 { return 0.5 * (x + y) }, 0.5, 0.5},
+		{"away from the right", func(x, y float64) float64 { return -x }, -1, 0},
+		{"nothing", func(x, y float64) float64 { return 0 }, 0, 0},
+	}
+	var first []float64
+	for _, tt := range tests {
+		outputs := make([]float64, len(points))
+		for i, p := range points {
+			outputs[i] = tt.output(p.X, p.Y)
+		}
+		steer := r.Steer(outputs)
+		if math.Abs(steer[0]-tt.dx) > 1e-12 || math.Abs(steer[1]-tt.dy) > 1e-12 {
+			t.Errorf("%s: steer %v, want [%v %v]", tt.name, steer, tt.dx, tt.dy)
+		}
+		if first == nil {
+			first = steer
+		} else if &steer[0] != &first[0] {
+			t.Fatal("Steer allocated a new slice")
+		}
+	}
+	if r.Substrate() != r.Substrate() {
+		t.Fatal("Substrate is built again on every call")
+	}
+}
@@ -0,0 +1,481 @@
+// Package sim contains the rules of the EVA world without any rendering,
+// so it can be stepped headlessly (training) or drawn by GameScene.
+package sim
+
+import (
+	"image"
+	"math"
+	"math/rand/v2"
+	"projectEVA/components"
+	"projectEVA/constants"
+	"projectEVA/data"
+
+	"github.com/gbatagian/deepsort"
+)
+
+const FramesPerSecond = 60
+
+type Config struct {
+	Diet           int // diet of the player (0 - carnivore, 1 - herbivore, 2 - omnivore)
+	LifetimeFrames int // how many frames a single genom is allowed to live
+	Seed           uint64
+	Fitness        data.FitnessFunc // scores episodes (nil - data.DefaultFitness)
+	Retina         *Retina          // HyperNEAT: genomes are CPPNs painting this retina's substrate (nil - genomes control the player directly)
+}
+
+type World struct {
+	Config          Config
+	Player          *Player
+	Enemies         []*Enemy
+	Vitamins        []*Vitamin
+	Colliders       []image.Rectangle
+	VitaminDuration float64
+	CaloryCount     bool
+	GameOver        bool
+	FoodEaten       int
+	EnemyKilled     int
+	TimePassed      int
+	Score           int
+	NumberOfFood    int
+	NumberOfEnemies int
+
+	// what the player can see, sorted by distance
+	NearEnemies  [][]float64 // {distance, angle, hp}
+	NearFoods    [][]float64 // {distance, angle}, up to 10 closest
+	NearVitamins [][]float64 // {distance, angle}, up to 10 closest
+
+	// counters of the whole episode, FoodEaten, EnemyKilled and TimePassed are reset by every evolution
+	episode     data.EpisodeStats
+	caloriesSum float64
+	visited     [BehaviourGrid * BehaviourGrid]int // frames spent in every cell of the map, see Behaviour
+
+	rng *rand.Rand // spawning and wandering, seeded from Config.Seed
+
+	// with Config.Retina: the world's own copy of it (Config may be shared by worlds playing
+	// in parallel, a retina keeps its buffers) and the substrate network ControlBy built for retinaOf
+	ownRetina  *Retina
+	retinaFrom *Retina
+	retinaNet  *data.SubstrateNetwork
+	retinaOf   *data.Genom
+}
+
+func NewWorld(cfg Config) *World {
+	w := &World{
+		Config: cfg,
+		rng:    rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+	}
+	w.Reset()
+	return w
+}
+
+// Restart reseeds the world and puts it back to its starting state
+func (w *World) Restart(seed uint64) {
+	w.Config.Seed = seed
+	w.rng = rand.New(rand.NewPCG(seed, seed))
+	w.Reset()
+}
+
+// Reset puts the world back to its starting state, keeping the config
+func (w *World) Reset() {
+	w.Player = NewPlayer(w.Config.Diet)
+	w.Enemies = []*Enemy{}
+	w.Vitamins = []*Vitamin{}
+	w.Colliders = []image.Rectangle{}
+	w.VitaminDuration = 0
+	w.CaloryCount = true
+	w.GameOver = false
+	w.FoodEaten = 0
+	w.EnemyKilled = 0
+	w.TimePassed = 0
+	w.Score = 0
+	w.NumberOfFood = 0
+	w.NumberOfEnemies = 0
+	w.NearEnemies = make([][]float64, 0)
+	w.NearFoods = make([][]float64, 0)
+	w.NearVitamins = make([][]float64, 0)
+	w.episode = data.EpisodeStats{}
+	w.caloriesSum = 0
+	w.visited = [BehaviourGrid * BehaviourGrid]int{}
+}
+
+// Done reports whether the current genom's life is over
+func (w *World) Done() bool {
+	return w.GameOver || w.TimePassed >= w.Config.LifetimeFrames
+}
+
+// EvaluateFitness scores genom for the episode played in this world with Config.Fitness
+// and keeps what it did in genom.Stats and genom.Behaviour
+func (w *World) EvaluateFitness(genom *data.Genom) float64 {
+	fitness := w.Config.Fitness
+	if fitness == nil {
+		fitness = data.FitnessFuncs[data.DefaultFitness]
+	}
+	genom.Behaviour = w.Behaviour()
+	return genom.Evaluate(fitness, w.Stats())
+}
+
+// Stats summarizes the episode played so far
+func (w *World) Stats() data.EpisodeStats {
+	stats := w.episode
+	stats.Seed = w.Config.Seed
+	stats.LifetimeFrames = w.Config.LifetimeFrames
+	stats.Died = w.GameOver
+	stats.Score = w.Score
+	stats.FoodEaten = w.FoodEaten
+	stats.EnemiesKilled = w.EnemyKilled
+	stats.TimeSurvived = w.TimePassed
+	stats.HP = w.Player.CombatComp.Health()
+	if stats.FramesLived > 0 {
+		stats.MeanCalories = w.caloriesSum / float64(stats.FramesLived)
+	}
+	stats.FinalCalories = w.Player.Calories
+	return stats
+}
+
+// Step advances the world by a single frame
+// player's Dx and Dy have to be set beforehand (by keyboard or by AI)
+func (w *World) Step() {
+	if w.GameOver {
+		return
+	}
+	player := w.Player
+
+	// Calories
+	if w.CaloryCount {
+		player.Calories -= 0.1 * player.Efficiency * player.EfficiencyMultiplier
+		w.TimePassed += 1
+	}
+
+	// Evolution &BALANCE
+	if player.Calories >= 1000 {
+		w.evolve()
+	}
+
+	// Player movement
+	x, y := player.X, player.Y
+	player.X += player.Dx
+	CheckCollisionHorizontal(&player.Body, w.Colliders)
+
+	player.Y += player.Dy
+	CheckCollisionVertical(&player.Body, w.Colliders)
+	w.episode.Distance += math.Hypot(player.X-x, player.Y-y)
+
+	w.updateEnemies()
+	w.updateVitamins()
+
+	// vitamine countdown
+	if w.VitaminDuration > 0 {
+		w.VitaminDuration--
+	} else if w.VitaminDuration == 0 {
+		w.CaloryCount = true
+		player.SpeedMultiplier = 1
+		player.EfficiencyMultiplier = 1
+		player.TempHP = 0
+		player.CombatComp = components.NewPlayerCombat(player.MaxHealth+player.TempHP, player.Dmg, 6000)
+		w.VitaminDuration--
+	}
+
+	// Teleport map edge
+	player.wrap()
+	for _, enemy := range w.Enemies {
+		enemy.wrap()
+	}
+
+	w.spawnFood()
+	w.spawnVitamin()
+	w.spawnEnemy()
+
+	w.look()
+
+	if player.Calories < 0 {
+		w.GameOver = true
+	}
+
+	w.episode.FramesLived++
+	w.visit()
+	w.caloriesSum += player.Calories
+	if w.episode.FramesLived == 1 || player.Calories < w.episode.MinCalories {
+		w.episode.MinCalories = player.Calories
+	}
+}
+
+func (w *World) evolve() {
+	// evolution rules - aggressive play gives aggressive stats,
+	// avoiding conflict leads to tank/sloth stats
+	player := w.Player
+	if w.TimePassed < 3600 {
+		player.Speed += 1
+		player.Efficiency += 0.1
+	} else {
+		player.Speed -= 1
+		player.Efficiency -= 0.1
+	}
+
+	if w.EnemyKilled > 2 {
+		player.Dmg += 1
+	} else {
+		player.MaxHealth += 1
+	}
+
+	if w.FoodEaten > 10 {
+		player.Efficiency -= 0.1
+		player.MaxHealth += 1
+	} else {
+		player.Efficiency -= 0.1
+		player.Speed += 1
+	}
+	player.CombatComp = components.NewPlayerCombat(player.MaxHealth+player.TempHP, player.Dmg, 6000)
+	w.FoodEaten = 0
+	w.EnemyKilled = 0
+	w.TimePassed = 0
+	player.Calories = 500
+	w.episode.Evolutions++
+}
+
+func (w *World) updateEnemies() {
+	player := w.Player
+	pRect := player.Rect()
+
+	deadEnemies := make(map[int]struct{})
+	w.NumberOfEnemies = 0
+	w.NumberOfFood = 0
+	for index, enemy := range w.Enemies {
+		if enemy.Type != 2 {
+			w.NumberOfFood++
+		} else {
+			w.NumberOfEnemies++
+		}
+		enemy.Dx = 0.0
+		enemy.Dy = 0.0
+
+		if enemy.Follows {
+			enemy.FollowsTarget(&player.Body, constants.EnemyPlayerVision, w.rng)
+		}
+		enemy.CombatComp.Update()
+		player.CombatComp.Update()
+		rect := enemy.Rect()
+		enemy.X += enemy.Dx
+		CheckCollisionHorizontal(&enemy.Body, w.Colliders)
+		enemy.Y += enemy.Dy
+		CheckCollisionVertical(&enemy.Body, w.Colliders)
+
+		// enemy eating food
+		if enemy.Type == 2 {
+			for index2, food := range w.Enemies {
+				if food.Type == 0 {
+					enemy.CombatComp.Update()
+					if rect.Overlaps(food.Rect()) {
+						if enemy.CombatComp.Attack() {
+							food.CombatComp.Damage(enemy.CombatComp.AttackPower())
+							if food.CombatComp.Health() <= 0 {
+								deadEnemies[index2] = struct{}{}
+							}
+						}
+					}
+				}
+			}
+		}
+		if rect.Overlaps(pRect) {
+			// enemy attack player
+			if enemy.CombatComp.Attack() {
+				player.CombatComp.Damage(enemy.CombatComp.AttackPower())
+				w.episode.DamageTaken += enemy.CombatComp.AttackPower()
+				if player.CombatComp.Health() <= 0 {
+					w.GameOver = true
+				}
+			}
+			// player attack enemy
+			if player.Diet == enemy.Type || player.Diet == 2 || enemy.Type == 2 {
+				if player.CombatComp.Attack() {
+					enemy.CombatComp.Damage(player.CombatComp.AttackPower())
+					w.episode.DamageDealt += player.CombatComp.AttackPower()
+					if enemy.CombatComp.Health() <= 0 {
+						w.eat(enemy)
+						deadEnemies[index] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+	if len(deadEnemies) > 0 {
+		newEnemies := make([]*Enemy, 0)
+		for index, enemy := range w.Enemies {
+			if _, exists := deadEnemies[index]; !exists {
+				newEnemies = append(newEnemies, enemy)
+			}
+		}
+		w.Enemies = newEnemies
+	}
+}
+
+func (w *World) eat(enemy *Enemy) {
+	// gives the player calories and score for a killed enemy or eaten food
+	player := w.Player
+	if enemy.Type == 2 {
+		if player.Diet == 2 {
+			player.Calories += 100
+			w.Score += 100
+		} else if player.Diet == 0 {
+			player.Calories += 200
+			w.Score += 200
+		}
+		w.EnemyKilled += 1
+		w.episode.TotalEnemiesKilled++
+	} else {
+		if player.Diet == 2 {
+			player.Calories += 25
+			w.Score += 25
+		} else {
+			player.Calories += 50
+			w.Score += 50
+		}
+		w.FoodEaten += 1
+		w.episode.TotalFoodEaten++
+	}
+}
+
+func (w *World) updateVitamins() {
+	player := w.Player
+	pRect := player.Rect()
+
+	deadVitamins := make(map[int]struct{})
+	for index, vitamin := range w.Vitamins {
+		vitamin.CombatComp.Update()
+		player.CombatComp.Update()
+
+		if vitamin.Rect().Overlaps(pRect) {
+			if player.CombatComp.Attack() {
+				vitamin.CombatComp.Damage(1)
+				deadVitamins[index] = struct{}{}
+				w.episode.VitaminsTaken++
+				player.SpeedMultiplier = vitamin.Speed
+				player.EfficiencyMultiplier = vitamin.Efficiency
+				player.TempHP = vitamin.TempHP
+				if vitamin.StopCalory {
+					w.CaloryCount = false
+				}
+				w.VitaminDuration = vitamin.Duration * 60
+				player.CombatComp = components.NewPlayerCombat(player.MaxHealth+player.TempHP, player.Dmg, 3000)
+			}
+		}
+	}
+	if len(deadVitamins) > 0 {
+		newVitamins := make([]*Vitamin, 0)
+		for index, vitamin := range w.Vitamins {
+			if _, exists := deadVitamins[index]; !exists {
+				newVitamins = append(newVitamins, vitamin)
+			}
+		}
+		w.Vitamins = newVitamins
+	}
+}
+
+func (w *World) spawnFood() {
+	if w.NumberOfFood >= constants.FoodLimit {
+		return
+	}
+	if w.rng.IntN(2)%2 == 0 {
+		w.Enemies = append(w.Enemies, &Enemy{
+			Body: Body{
+				X:    float64(randRange(w.rng, 0, constants.GameWidth)),
+				Y:    float64(randRange(w.rng, 0, constants.GameHeight)),
+				Size: constants.FoodSize,
+			},
+			Follows:    false,
+			CombatComp: components.NewEnemyCombat(1, 0, 30),
+			Type:       w.rng.IntN(2),
+		})
+	}
+}
+
+func (w *World) spawnVitamin() {
+	if len(w.Vitamins) >= constants.VitaminLimit {
+		return
+	}
+	if w.rng.IntN(2)%2 == 0 {
+		vitamin := &Vitamin{
+			Body: Body{
+				X:    float64(randRange(w.rng, 0, constants.GameWidth)),
+				Y:    float64(randRange(w.rng, 0, constants.GameHeight)),
+				Size: constants.VitaminSize,
+			},
+			CombatComp: components.NewEnemyCombat(1, 0, 0),
+			Speed:      0.5,
+			Efficiency: 0.5,
+			TempHP:     0,
+			Duration:   3,
+			StopCalory: false,
+			Type:       w.rng.IntN(3),
+		}
+		switch vitamin.Type {
+		case 1:
+			vitamin.Speed = 1.5
+			vitamin.Efficiency = 1.5
+		case 2:
+			vitamin.Speed = 1
+			vitamin.Efficiency = 1
+			vitamin.TempHP = 10
+		case 3:
+			vitamin.Speed = 1
+			vitamin.Efficiency = 1
+			vitamin.StopCalory = true
+		}
+		w.Vitamins = append(w.Vitamins, vitamin)
+	}
+}
+
+func (w *World) spawnEnemy() {
+	if w.NumberOfEnemies >= constants.EnemyLimit {
+		return
+	}
+	player := w.Player
+	w.Enemies = append(w.Enemies, &Enemy{
+		Body: Body{
+			X:    float64(randRange(w.rng, 0, constants.GameWidth)),
+			Y:    float64(randRange(w.rng, 0, constants.GameHeight)),
+			Size: 1,
+		},
+		Follows:    true,
+		CombatComp: components.NewEnemyCombat(float64(randRange(w.rng, int(player.MaxHealth*0.9), int(player.MaxHealth*1.1))), math.Max(1, float64(randRange(w.rng, int(player.Dmg*0.9), int(player.Dmg*1.1)))), 3000),
+		Type:       2,
+		Speed:      float64(randRange(w.rng, int(player.Speed*1.0), int(player.Speed*1.2))),
+	})
+}
+
+func (w *World) look() {
+	// updates what the player sees - nearest enemies, food and vitamins
+	player := w.Player
+	w.NearEnemies = make([][]float64, 0)
+	w.NearFoods = make([][]float64, 0)
+	w.NearVitamins = make([][]float64, 0)
+
+	for _, vitamin := range w.Vitamins {
+		w.NearVitamins = append(w.NearVitamins, []float64{player.distance(&vitamin.Body), player.angle(&vitamin.Body)})
+	}
+	for _, enemy := range w.Enemies {
+		if enemy.Type == 2 {
+			w.NearEnemies = append(w.NearEnemies, []float64{player.distance(&enemy.Body), player.angle(&enemy.Body), enemy.CombatComp.Health()})
+		}
+		if player.canEat(enemy) {
+			w.NearFoods = append(w.NearFoods, []float64{player.distance(&enemy.Body), player.angle(&enemy.Body)})
+		}
+	}
+	deepsort.DeepSort(&w.NearEnemies, []float64{0})
+	deepsort.DeepSort(&w.NearFoods, []float64{0})
+	deepsort.DeepSort(&w.NearVitamins, []float64{0})
+	if len(w.NearFoods) > 10 {
+		w.NearFoods = w.NearFoods[:10]
+	}
+	if len(w.NearVitamins) > 10 {
+		w.NearVitamins = w.NearVitamins[:10]
+	}
+}
+
+func (b *Body) distance(target *Body) float64 {
+	return math.Sqrt(math.Pow(b.X-target.X, 2) + math.Pow(b.Y-target.Y, 2))
+}
+
+func (b *Body) angle(target *Body) float64 {
+	// angle to the target in degrees
+	return math.Atan2(target.Y-b.Y, target.X-b.X) * (180 / math.Pi)
+}
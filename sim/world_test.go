@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"projectEVA/components"
+	"projectEVA/constants"
+	"testing"
+)
+
+func TestPlayerEatsFood(t *testing.T) {
+	w := NewWorld(Config{Diet: 1, LifetimeFrames: 100})
+	player := w.Player
+	// no attack cooldown, so the plant is eaten on the first frame
+	player.CombatComp = components.NewPlayerCombat(3, 1, 0)
+	plant := &Enemy{
+		Body:       Body{X: player.X, Y: player.Y, Size: 1},
+		CombatComp: components.NewEnemyCombat(1, 0, 30),
+		Type:       1,
+	}
+	w.Enemies = append(w.Enemies, plant)
+	calories := player.Calories
+
+	w.Step()
+	if w.FoodEaten != 1 || w.Score != 50 {
+		t.Fatalf("food eaten %d, score %d, want 1 and 50", w.FoodEaten, w.Score)
+	}
+	if player.Calories <= calories {
+		t.Fatalf("calories %v after eating, had %v", player.Calories, calories)
+	}
+	for _, enemy := range w.Enemies {
+		if enemy == plant {
+			t.Fatal("the eaten plant is still on the map")
+		}
+	}
+}
+
+func TestMapWraps(t *testing.T) {
+	w := NewWorld(Config{LifetimeFrames: 100})
+	w.Player.X = constants.GameWidth + 3
+	w.Player.Y = -2
+	w.Step()
+	if w.Player.X != 1 || w.Player.Y != constants.GameHeight-1 {
+		t.Fatalf("player at (%v, %v), want (1, %v)", w.Player.X, w.Player.Y, constants.GameHeight-1)
+	}
+
+	w.Player.X, w.Player.Y = -1, constants.GameHeight
+	w.Step()
+	if w.Player.X != constants.GameWidth-1 || w.Player.Y != 1 {
+		t.Fatalf("player at (%v, %v), want (%v, 1)", w.Player.X, w.Player.Y, constants.GameWidth-1)
+	}
+}
+
+func TestLifetime(t *testing.T) {
+	w := NewWorld(Config{LifetimeFrames: 30})
+	for frame := 0; frame < 30; frame++ {
+		if w.Done() {
+			t.Fatalf("the life is over after %d frames, want 30", frame)
+		}
+		w.Step()
+	}
+	if !w.Done() {
+		t.Fatal("the life isn't over after 30 frames")
+	}
+}
+
+func TestSpawning(t *testing.T) {
+	w := NewWorld(Config{LifetimeFrames: 100})
+	for i := 0; i < 50; i++ {
+		w.Step()
+	}
+	if w.NumberOfEnemies == 0 || len(w.Vitamins) == 0 {
+		t.Fatalf("%d enemies and %d vitamins after 50 frames", w.NumberOfEnemies, len(w.Vitamins))
+	}
+	if len(w.Vitamins) > constants.VitaminLimit || w.NumberOfEnemies > constants.EnemyLimit {
+		t.Fatalf("%d enemies and %d vitamins, above the limits", w.NumberOfEnemies, len(w.Vitamins))
+	}
+	for _, enemy := range w.Enemies {
+		if enemy.X < 0 || enemy.X > constants.GameWidth || enemy.Y < 0 || enemy.Y > constants.GameHeight {
+			t.Fatalf("enemy at (%v, %v) is off the map", enemy.X, enemy.Y)
+		}
+	}
+}
+
+func TestInputs(t *testing.T) {
+	w := NewWorld(Config{LifetimeFrames: 100})
+	inputs := w.Inputs()
+	if len(inputs) != NumInputs {
+		t.Fatalf("%d inputs, want %d", len(inputs), NumInputs)
+	}
+	// nothing seen yet - every target is as far as it gets
+	if inputs[8] != 1 || inputs[10] != 1 || inputs[12] != 1 {
+		t.Fatalf("inputs %v, want distance 1 for everything unseen", inputs)
+	}
+	for i, v := range inputs {
+		if v < -1 || v > 1 {
+			t.Fatalf("input %d = %v, out of [-1, 1]", i, v)
+		}
+	}
+}
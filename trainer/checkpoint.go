@@ -0,0 +1,137 @@
+package trainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"projectEVA/data"
+)
+
+// CheckpointFormatVersion is written to every checkpoint
+const CheckpointFormatVersion = 1
+
+// CheckpointFile is the name of the checkpoint written to Config.OutDir
+const CheckpointFile = "checkpoint.json"
+
+// DefaultHallOfFame is the number of all-time champions kept when a config doesn't say
+const DefaultHallOfFame = 10
+
+// ChampionsFile is the name of the champion archive written to Config.OutDir after every generation
+const ChampionsFile = "champions.json"
+
+// checkpoint is everything needed to continue a run exactly where it stopped.
+// Population carries the innovation history, generation counter and threshold,
+// Genoms is the bred (not yet evaluated) generation.
+type checkpoint struct {
+	Version    int                   `json:"version"`
+	Config     Config                `json:"config"`
+	Population *data.Population      `json:"population"`
+	Genoms     []*data.Genom         `json:"genoms"`
+	Archive    *data.ChampionArchive `json:"archive"`
+	RNG        []byte                `json:"rng"`        // state of the PCG source
+	LogOffset  int64                 `json:"log_offset"` // size of best_fitness_log.csv when the checkpoint was taken
+}
+
+// SaveCheckpoint writes the state of the run to filename.
+// The file is replaced atomically, so a run killed while saving keeps the previous checkpoint.
+func (t *Trainer) SaveCheckpoint(filename string) error {
+	state, err := t.src.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(checkpoint{
+		Version:    CheckpointFormatVersion,
+		Config:     t.Config,
+		Population: t.Population,
+		Genoms:     t.Genoms,
+		Archive:    t.Archive,
+		RNG:        state,
+		LogOffset:  t.logOffset,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// Resume loads a checkpoint written by SaveCheckpoint. Fitness log lines
+// appended after the checkpoint was taken are dropped, so they aren't logged twice.
+func Resume(filename string) (*Trainer, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if cp.Version != CheckpointFormatVersion {
+		return nil, fmt.Errorf("%s: unsupported checkpoint format version %d (supported: %d)", filename, cp.Version, CheckpointFormatVersion)
+	}
+	if cp.Population == nil {
+		return nil, fmt.Errorf("%s: checkpoint has no population", filename)
+	}
+	if cp.Archive == nil {
+		return nil, fmt.Errorf("%s: checkpoint has no champion archive", filename)
+	}
+	if err := cp.Population.Config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	src := &rand.PCG{}
+	if err := src.UnmarshalBinary(cp.RNG); err != nil {
+		return nil, fmt.Errorf("%s: rng state: %w", filename, err)
+	}
+	t := &Trainer{
+		Config:     cp.Config,
+		Population: cp.Population,
+		Genoms:     cp.Genoms,
+		IH:         cp.Population.IH,
+		Archive:    cp.Archive,
+		src:        src,
+		rng:        rand.New(src),
+		logOffset:  cp.LogOffset,
+	}
+	t.Config.NEAT = &t.Population.Config
+	t.attach()
+
+	logFile := t.logFile()
+	if info, err := os.Stat(logFile); err == nil && info.Size() > cp.LogOffset {
+		if err := os.Truncate(logFile, cp.LogOffset); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// attach points every genom and the population at the trainer's
+// innovation history and random source
+func (t *Trainer) attach() {
+	t.Population.IH = t.IH
+	t.Population.Rand = t.rng
+	for _, species := range t.Population.AllSpecies {
+		for _, genom := range species.Genoms {
+			genom.IH = t.IH
+			genom.Rand = t.rng
+		}
+	}
+	for _, genom := range t.Genoms {
+		genom.IH = t.IH
+		genom.Rand = t.rng
+	}
+}
+
+func (t *Trainer) logFile() string {
+	return filepath.Join(t.Config.OutDir, "best_fitness_log.csv")
+}
+
+func (t *Trainer) checkpointDue() bool {
+	every := t.Config.CheckpointEvery
+	return every > 0 && t.Population.CurrentGeneration%every == 0
+}
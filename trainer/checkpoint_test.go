@@ -0,0 +1,71 @@
+package trainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testConfig(dir string, generations int) Config {
+	return Config{PopSize: 10, Generations: generations, LifetimeSeconds: 2, Seed: 7, OutDir: dir, Workers: 2, HallOfFame: 3}
+}
+
+func sameFile(t *testing.T, a, b string) {
+	t.Helper()
+	if readFile(t, a) != readFile(t, b) {
+		t.Fatalf("%s and %s differ", a, b)
+	}
+}
+
+func TestResumeMatchesStraightRun(t *testing.T) {
+	const n, k = 4, 2
+	straight, resumed := t.TempDir(), t.TempDir()
+	if err := New(testConfig(straight, n)).Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	first := New(testConfig(resumed, k))
+	if err := first.Run(); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := filepath.Join(resumed, CheckpointFile)
+	if err := first.SaveCheckpoint(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	logFile := filepath.Join(resumed, "best_fitness_log.csv")
+	info, err := os.Stat(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := info.Size()
+	// the run goes on and gets killed before the next checkpoint,
+	// the generation it logged in the meantime is played again after resuming
+	first.Evaluate()
+	if err := first.NextGeneration(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(logFile); err != nil || info.Size() <= saved {
+		t.Fatal("the extra generation wasn't logged")
+	}
+
+	second, err := Resume(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(logFile); err != nil || info.Size() != saved {
+		t.Fatalf("Resume didn't truncate the fitness log to %d bytes", saved)
+	}
+	if second.Population.CurrentGeneration != k {
+		t.Fatalf("resumed at generation %d, want %d", second.Population.CurrentGeneration, k)
+	}
+	second.Config.Generations = n
+	if err := second.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	sameFile(t, filepath.Join(straight, "best_fitness_log.csv"), logFile)
+	sameFile(t, filepath.Join(straight, ChampionsFile), filepath.Join(resumed, ChampionsFile))
+	for _, name := range []string{"generation_3.json", "generation_3.txt"} {
+		sameFile(t, filepath.Join(straight, "generations", name), filepath.Join(resumed, "generations", name))
+	}
+}
@@ -0,0 +1,221 @@
+// Package trainer evolves a NEAT population on headless sim worlds,
+// so training doesn't need a window and doesn't run at 60 FPS.
+package trainer
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"projectEVA/data"
+	"projectEVA/sim"
+)
+
+type Config struct {
+	PopSize         int    `json:"pop_size"`         // number of genomes in every generation
+	Generations     int    `json:"generations"`      // Run stops when the population reaches this generation
+	LifetimeSeconds int    `json:"lifetime_seconds"` // how long a single genom lives in the world
+	Seed            uint64 `json:"seed"`             // seed for the worlds the genomes are evaluated in
+	OutDir          string `json:"out_dir"`          // where populations, checkpoints and the fitness log are written
+	Diet            int    `json:"diet"`             // diet of the player (0 - carnivore, 1 - herbivore, 2 - omnivore)
+	Workers         int    `json:"workers"`          // number of genomes evaluated at the same time
+	CheckpointEvery int    `json:"checkpoint_every"` // write a checkpoint every N generations, 0 turns checkpoints off
+	Recurrent       bool   `json:"recurrent"`        // evolve recurrent networks, which remember things between frames
+	HallOfFame      int    `json:"hall_of_fame"`     // number of all-time champions kept in the archive
+	Fitness         string `json:"fitness"`          // name of the fitness function, one of data.FitnessFuncs ("" - data.DefaultFitness)
+	HyperNEAT       bool   `json:"hyperneat"`        // genomes are CPPNs painting the weights of sim.DefaultRetina
+
+	// NEAT hyperparameters for a new population (nil - data.DefaultNEATConfig)
+	// they are saved with the population, so checkpoints don't repeat them here
+	NEAT *data.NEATConfig `json:"-"`
+}
+
+type Trainer struct {
+	Config     Config
+	Population *data.Population
+	Genoms     []*data.Genom // genomes of the current generation
+	IH         *data.InnovationHistory
+	Archive    *data.ChampionArchive // best genomes of the run, saved to ChampionsFile
+	src        *rand.PCG             // kept next to rng, so its state can be checkpointed
+	rng        *rand.Rand            // the only source of randomness of a run - breeding and world seeds
+	logOffset  int64                 // size of the fitness log after the last generation was logged
+}
+
+func New(cfg Config) *Trainer {
+	// creates a trainer with a fresh, random population
+	src := rand.NewPCG(cfg.Seed, 0)
+	t := &Trainer{
+		Config:  cfg,
+		IH:      &data.InnovationHistory{},
+		Archive: newArchive(cfg),
+		src:     src,
+		rng:     rand.New(src),
+	}
+	neat := data.DefaultNEATConfig()
+	if cfg.NEAT != nil {
+		neat = *cfg.NEAT
+	}
+	t.Population = &data.Population{
+		PopSize:   cfg.PopSize,
+		Config:    neat,
+		Threshold: neat.InitialThreshold,
+		IH:        t.IH,
+		Rand:      t.rng,
+	}
+	t.Config.NEAT = &t.Population.Config
+	numInputs, numOutputs := sim.NumInputs, sim.NumOutputs
+	if cfg.HyperNEAT {
+		numInputs, numOutputs = data.CPPNInputs, data.CPPNOutputs
+	}
+	for i := 0; i < cfg.PopSize; i++ {
+		genom := &data.Genom{
+			NumInputs:        numInputs,
+			NumOutputs:       numOutputs,
+			ConnCreationRate: neat.ConnCreationRate,
+			IH:               t.IH,
+			Rand:             t.rng,
+			Recurrent:        cfg.Recurrent,
+		}
+		genom.CreateNetwork()
+		t.Genoms = append(t.Genoms, genom)
+	}
+	return t
+}
+
+func newArchive(cfg Config) *data.ChampionArchive {
+	// the archive remembers the HyperNEAT mode, so CPPN champions can be played through the retina later
+	a := data.NewChampionArchive(cfg.HallOfFame)
+	a.HyperNEAT = cfg.HyperNEAT
+	return a
+}
+
+// FromPopulation creates a trainer which continues evolving an already existing
+// population (e.g. one loaded with data.LoadPopulationFromFile), starting by
+// evaluating all of its genomes again
+func FromPopulation(cfg Config, pop *data.Population) *Trainer {
+	src := rand.NewPCG(cfg.Seed, 0)
+	t := &Trainer{
+		Config:     cfg,
+		Population: pop,
+		Genoms:     data.AllGenomesFromPopulation(pop),
+		IH:         pop.IH,
+		Archive:    newArchive(cfg),
+		src:        src,
+		rng:        rand.New(src),
+	}
+	if t.IH == nil {
+		t.IH = &data.InnovationHistory{}
+	}
+	t.Config.NEAT = &pop.Config
+	t.attach()
+	return t
+}
+
+func (t *Trainer) worldConfig() sim.Config {
+	// an unknown fitness name is reported by Run, the world falls back to the default one
+	fitness, _ := data.LookupFitness(t.Config.Fitness)
+	return sim.Config{
+		Diet:           t.Config.Diet,
+		LifetimeFrames: t.Config.LifetimeSeconds * sim.FramesPerSecond,
+		Fitness:        fitness,
+		Retina:         t.Retina(),
+	}
+}
+
+// Retina is the substrate the genomes paint in HyperNEAT mode (nil - they control the player directly)
+func (t *Trainer) Retina() *sim.Retina {
+	if !t.Config.HyperNEAT {
+		return nil
+	}
+	r := sim.DefaultRetina
+	return &r
+}
+
+// Evaluate plays an episode for every genom of the current generation
+// (Config.Workers at a time) and stores the result in its Fitness
+func (t *Trainer) Evaluate() {
+	// seeds are drawn up front, in genom order, so parallel runs stay reproducible
+	seeds := make([]uint64, len(t.Genoms))
+	for i := range seeds {
+		seeds[i] = t.rng.Uint64()
+	}
+	sim.EvaluateAll(t.Genoms, t.worldConfig(), seeds, t.Config.Workers)
+}
+
+// NextGeneration logs and saves the evaluated generation, then breeds the next one.
+// Every Config.CheckpointEvery generations the bred generation is checkpointed.
+func (t *Trainer) NextGeneration() error {
+	pop := t.Population
+	logFile := t.logFile()
+	if err := data.AppendBestFitnessLogTo(logFile, pop.CurrentGeneration, t.Genoms); err != nil {
+		return err
+	}
+	info, err := os.Stat(logFile)
+	if err != nil {
+		return err
+	}
+	t.logOffset = info.Size()
+	pop.EvaluateNovelty(t.Genoms)
+	pop.Speciate(t.Genoms)
+	t.Archive.Record(pop)
+	if err := data.SaveChampionArchive(filepath.Join(t.Config.OutDir, ChampionsFile), t.Archive); err != nil {
+		return err
+	}
+	genDir := filepath.Join(t.Config.OutDir, "generations")
+	if err := data.SavePopulationToDir(genDir, pop, pop.CurrentGeneration); err != nil {
+		return err
+	}
+	jsonFile := filepath.Join(genDir, fmt.Sprintf("generation_%d.json", pop.CurrentGeneration))
+	if err := data.SavePopulationJSON(jsonFile, pop); err != nil {
+		return err
+	}
+	paretoFile := filepath.Join(genDir, fmt.Sprintf("pareto_front_%d.csv", pop.CurrentGeneration))
+	if err := data.SaveParetoFront(paretoFile, pop); err != nil {
+		return err
+	}
+
+	pop.CurrentGeneration++
+	t.Genoms = data.GenerateNewPopulation(pop)
+	if len(t.Genoms) == 0 {
+		return fmt.Errorf("generation %d: no genomes were bred", pop.CurrentGeneration)
+	}
+	if t.checkpointDue() {
+		return t.SaveCheckpoint(filepath.Join(t.Config.OutDir, CheckpointFile))
+	}
+	return nil
+}
+
+// Run evolves the population until it reaches generation Config.Generations
+// (a resumed run only evolves the generations that are left)
+func (t *Trainer) Run() error {
+	if _, err := data.LookupFitness(t.Config.Fitness); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.Config.OutDir, os.ModePerm); err != nil {
+		return err
+	}
+	for t.Population.CurrentGeneration < t.Config.Generations {
+		t.Evaluate()
+		best, avg := fitnessSummary(t.Genoms)
+		log.Printf("generation %d: best fitness %.2f, average %.2f", t.Population.CurrentGeneration, best, avg)
+		if err := t.NextGeneration(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fitnessSummary(genoms []*data.Genom) (best, avg float64) {
+	if len(genoms) == 0 {
+		return 0, 0
+	}
+	best = genoms[0].Fitness
+	for _, genom := range genoms {
+		avg += genom.Fitness
+		if genom.Fitness > best {
+			best = genom.Fitness
+		}
+	}
+	return best, avg / float64(len(genoms))
+}
@@ -0,0 +1,46 @@
+package trainer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runTrainer(t *testing.T, seed uint64) string {
+	dir := t.TempDir()
+	tr := New(Config{
+		PopSize:         10,
+		Generations:     3,
+		LifetimeSeconds: 2,
+		Seed:            seed,
+		OutDir:          dir,
+		Workers:         2,
+	})
+	if err := tr.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func readFile(t *testing.T, name string) string {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestSameSeedSameRun(t *testing.T) {
+	a := runTrainer(t, 7)
+	b := runTrainer(t, 7)
+	files := []string{
+		"best_fitness_log.csv",
+		filepath.Join("generations", "generation_0.txt"),
+		filepath.Join("generations", "generation_2.txt"),
+	}
+	for _, name := range files {
+		if readFile(t, filepath.Join(a, name)) != readFile(t, filepath.Join(b, name)) {
+			t.Errorf("%s differs between two runs with the same seed", name)
+		}
+	}
+}